@@ -13,11 +13,12 @@ package gromb
 //	+-------------+-------------+---------------+-------------+------------+------------+
 
 const (
-	MaxAsciiLen = 256
-	MinAsciiLen = 4
-	StartChar   = 0x3a
-	EndHigh     = 0x0d
-	EndLow      = 0x0a
+	MaxAsciiLen      = 256
+	MinAsciiLen      = 4
+	MaxAsciiFrameLen = 513 // 最大帧长度 (含起始符与结束符)
+	StartChar        = 0x3a
+	EndHigh          = 0x0d
+	EndLow           = 0x0a
 )
 
 // 封装报文 (Modbus Ascii)
@@ -27,23 +28,26 @@ func (m *Modbus) asciiPack(isReq bool) {
 		return
 	}
 
-	var hex []uint8
-	var box groBox
-	box.Init(&hex, MaxAsciiLen)
-	box.PutU8(m.Head.GetDevId())
-	box.AddLast(1)
+	m.Box.PutU8(StartChar)
+	m.Box.PutU8(m.Head.GetDevId())
+	m.Box.AddLast(2)
 
-	ret := Pack(&m.Result, &m.Arg, &box, isReq)
+	ret := Pack(&m.Result, &m.Arg, &m.Box, isReq)
 	if ret < 0 {
 		return
 	}
 	length := uint16(ret)
-	box.PutU8(LRCCalcul(box.GetBuffer(0, length+1)))
+	m.Box.SubLast(1)
+	m.Box.PutU8(LRCCalcul(m.Box.GetThisBuffer(0, length+1)))
 
-	m.Box.PutU8(StartChar)
-	m.Box.PutU8s(asciiFromHex(box.GetBuffer(0, length+2)))
+	// 地址/PDU/LRC 原地转换为十六进制字符, 避免中间缓冲区
+	m.Box.ExpandHex(0)
 	m.Box.PutU8(EndHigh)
-	m.Box.PutU8(EndLow)
+	m.Box.PutU8(m.Head.GetAsciiEnd())
+
+	if !isReq {
+		m.Diag.countResponse(m.Arg.GetFuncCode(), m.Result.GetExcepCode())
+	}
 
 	m.Result.SetResult(nil)
 	m.Result.SetRetLen(length*2 + 7)
@@ -62,12 +66,28 @@ func (m *Modbus) asciiParse(isReq bool) {
 	}
 
 	hex := asciiToHex(m.Box.GetBuffer(1, m.Box.Size()-2))
+	if hex == nil {
+		if isReq {
+			m.Diag.countCommError()
+		}
+		m.Result.SetResult(ErrResultAsciiChar)
+		return
+	}
+	if len(hex) < 1 {
+		m.Result.SetResult(ErrResultTooShort)
+		return
+	}
 
 	var box groBox
-	box.Init(&hex, 1024)
+	box.Init(hex, 1024)
 
 	if isReq {
-		if m.Access.FilterDevID != nil && !m.Access.FilterDevID(box.GetU8(0), m.Access.UserData) {
+		// 广播报文不经过设备ID过滤
+		if box.GetU8(0) != DevIdBroadcast && m.Access.FilterDevID != nil && !m.Access.FilterDevID(box.GetU8(0), m.Access.UserData) {
+			// 发往其他设备的报文, 校验正确时计入总线报文数
+			if asciiCheckLrc(hex) {
+				m.Diag.countBusMessage()
+			}
 			m.Result.SetResult(ErrResultDevID)
 			return
 		}
@@ -79,26 +99,52 @@ func (m *Modbus) asciiParse(isReq bool) {
 	}
 	m.Head.SetDevId(box.GetU8(0))
 
+	// 请求在解析 PDU 之前检查整帧的校验码, 损坏的报文 (如功能码未知) 同样计入通信错误
+	if isReq && !asciiCheckLrc(hex) {
+		m.Diag.countCommError()
+		m.Result.SetResult(ErrResultAsciiLrc)
+		return
+	}
+
 	box.AddLast(1)
-	ret := Parse(&m.Result, &m.Access, &m.Arg, &box, isReq)
+	ret := m.parsePdu(&box, len(hex)-2, isReq)
 	if ret < 0 {
 		return
 	}
 	length := uint16(ret)
+
+	// 检查校验码/结束符是否完整
+	if box.Size() < length+2 || m.Box.Size() < length*2+7 {
+		m.Result.SetResult(ErrResultTooShort)
+		return
+	}
 	{
 		box.SubLast(1)
 		lrc := LRCCalcul(box.GetBuffer(0, length+1))
 		if lrc != box.GetU8(length+1) {
+			if isReq {
+				m.Diag.countCommError()
+			}
 			m.Result.SetResult(ErrResultAsciiLrc)
 			return
 		}
 	}
 
-	if m.Box.GetU8(length*2+5) != EndHigh || m.Box.GetU8(length*2+6) != EndLow {
+	if m.Box.GetU8(length*2+5) != EndHigh || m.Box.GetU8(length*2+6) != m.Head.GetAsciiEnd() {
 		m.Result.SetResult(ErrResultAsciiEnd)
 		return
 	}
 
+	if isReq {
+		m.Diag.countServerMessage(m.Head.IsBroadcast())
+	}
+
 	m.Result.SetResult(nil)
 	m.Result.SetRetLen(length*2 + 7)
 }
+
+// 检查整帧的 LRC 校验码 (hex 为转换后的二进制报文)
+func asciiCheckLrc(hex []uint8) bool {
+	n := len(hex)
+	return n >= 2 && LRCCalcul(hex[:n-1]) == hex[n-1]
+}
@@ -42,6 +42,10 @@ func (m *Modbus) rtuPack(isReq bool) {
 		m.Box.PutU16(crc, binary.LittleEndian)
 	}
 
+	if !isReq {
+		m.Diag.countResponse(m.Arg.GetFuncCode(), m.Result.GetExcepCode())
+	}
+
 	m.Result.SetResult(nil)
 	m.Result.SetRetLen(length + 3)
 }
@@ -54,7 +58,12 @@ func (m *Modbus) rtuParse(isReq bool) {
 	}
 
 	if isReq {
-		if m.Access.FilterDevID != nil && !m.Access.FilterDevID(m.Box.GetU8(0), m.Access.UserData) {
+		// 广播报文不经过设备ID过滤
+		if m.Box.GetU8(0) != DevIdBroadcast && m.Access.FilterDevID != nil && !m.Access.FilterDevID(m.Box.GetU8(0), m.Access.UserData) {
+			// 发往其他设备的报文, 校验正确时计入总线报文数
+			if rtuCheckCrc(m.Box.GetBuffer(0, m.Box.Size())) {
+				m.Diag.countBusMessage()
+			}
 			m.Result.SetResult(ErrResultDevID)
 			return
 		}
@@ -66,22 +75,47 @@ func (m *Modbus) rtuParse(isReq bool) {
 	}
 	m.Head.SetDevId(m.Box.GetU8(0))
 
+	// 请求在解析 PDU 之前检查整帧的校验码, 损坏的报文 (如功能码未知) 同样计入通信错误
+	if isReq && !rtuCheckCrc(m.Box.GetBuffer(0, m.Box.Size())) {
+		m.Diag.countCommError()
+		m.Result.SetResult(ErrResultRtuCrc)
+		return
+	}
+
 	m.Box.AddLast(1)
-	ret := Parse(&m.Result, &m.Access, &m.Arg, &m.Box, isReq)
+	ret := m.parsePdu(&m.Box, int(m.Box.Size())-3, isReq)
 	if ret < 0 {
 		return
 	}
 	len := uint16(ret)
 
+	// 检查校验码是否完整
+	if m.Box.ThisSize() < len+2 {
+		m.Result.SetResult(ErrResultTooShort)
+		return
+	}
 	{
 		crc1 := CRC16(m.Box.GetBuffer(0, len+1))
 		crc2 := m.Box.GetU16(len, binary.LittleEndian)
 		if crc1 != crc2 {
+			if isReq {
+				m.Diag.countCommError()
+			}
 			m.Result.SetResult(ErrResultRtuCrc)
 			return
 		}
 	}
 
+	if isReq {
+		m.Diag.countServerMessage(m.Head.IsBroadcast())
+	}
+
 	m.Result.SetResult(nil)
 	m.Result.SetRetLen(len + 3)
 }
+
+// 检查整帧的 CRC 校验码
+func rtuCheckCrc(adu []uint8) bool {
+	n := len(adu)
+	return n >= MinRTULen && CRC16(adu[:n-2]) == binary.LittleEndian.Uint16(adu[n-2:])
+}
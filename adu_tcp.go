@@ -68,7 +68,11 @@ func (m *Modbus) tcpParse(isReq bool) {
 	}
 
 	number := m.Box.GetU16(4, binary.BigEndian)
-	if m.Box.ThisSize() < 6+number {
+	if number < 2 {
+		m.Result.SetResult(ErrResultLength)
+		return
+	}
+	if int(m.Box.ThisSize()) < 6+int(number) {
 		m.Result.SetResult(ErrResultTooShort)
 		return
 	}
@@ -87,7 +91,7 @@ func (m *Modbus) tcpParse(isReq bool) {
 	m.Head.SetDevId(m.Box.GetU8(6))
 
 	m.Box.AddLast(7)
-	ret := Parse(&m.Result, &m.Access, &m.Arg, &m.Box, isReq)
+	ret := m.parsePdu(&m.Box, int(number)-1, isReq)
 	if ret < 0 {
 		return
 	}
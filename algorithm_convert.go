@@ -33,62 +33,40 @@ const (
 
 func asciiToHex(src []uint8) []uint8 {
 	dst := make([]uint8, len(src)/2)
-	{
-		i, j := 0, 0
-		for j+1 < len(src) {
-			if src[j] == ' ' || src[j] == '\r' || src[j] == '\n' {
-				j++
-				continue
-			}
-
-			a := reverseHexTable[src[j]]
-			b := reverseHexTable[src[j+1]]
-
-			if a > 0x0f {
-				return nil
-			}
-			if b > 0x0f {
-				return nil
-			}
-
-			dst[i] = (a << 4) | b
-
-			i++
-			j += 2
+
+	i, j := 0, 0
+	for j+1 < len(src) {
+		if src[j] == ' ' || src[j] == '\r' || src[j] == '\n' {
+			j++
+			continue
 		}
-	}
-	return dst
-}
 
-func asciiFromHex(src []uint8) []uint8 {
-	dst := make([]uint8, len(src)*2)
-	{
-		j := 0
-		for _, v := range src {
-			dst[j] = hextable[v>>4]
-			dst[j+1] = hextable[v&0x0f]
-			j += 2
+		a := reverseHexTable[src[j]]
+		b := reverseHexTable[src[j+1]]
+
+		if a > 0x0f {
+			return nil
+		}
+		if b > 0x0f {
+			return nil
 		}
+
+		dst[i] = (a << 4) | b
+
+		i++
+		j += 2
 	}
-	return dst
+	return dst[:i]
 }
 
 func byteToUint16(in []uint8, order binary.ByteOrder) uint16 {
 	return order.Uint16(in[:])
 }
 
-func byteFromUint16(in uint16, order binary.ByteOrder) []uint8 {
-	var out []uint8 = make([]uint8, 2)
-	order.PutUint16(out, in)
-	return out
-}
-
-func bytesFromUint16s(input []uint16, order binary.ByteOrder) []uint8 {
-	output := make([]uint8, 2*len(input))
+func putUint16s(output []uint8, input []uint16, order binary.ByteOrder) {
 	for i := 0; i < len(input); i++ {
 		order.PutUint16(output[i*2:i*2+2], input[i])
 	}
-	return output
 }
 
 func bytesToUint16s(input []uint8, order binary.ByteOrder) []uint16 {
@@ -107,10 +85,99 @@ func bytesToFloat32s(input []uint8, order binary.ByteOrder) []float32 {
 	return output
 }
 
-func bytesFromFloat32s(input []float32, order binary.ByteOrder) []uint8 {
-	output := make([]uint8, len(input)*4)
+func putFloat32s(output []uint8, input []float32, order binary.ByteOrder) {
 	for i := 0; i < len(input); i++ {
 		order.PutUint32(output[i*4:i*4+4], math.Float32bits(input[i]))
 	}
-	return output
+}
+
+// 是否交换寄存器顺序 (低字在前)
+func (o WordOrder) swapWords() bool {
+	return o == OrderCDAB || o == OrderDCBA
+}
+
+// 是否交换寄存器内的字节 (低字节在前)
+func (o WordOrder) swapBytes() bool {
+	return o == OrderBADC || o == OrderDCBA
+}
+
+// 单个寄存器的字节序
+func (o WordOrder) byteOrder() binary.ByteOrder {
+	if o.swapBytes() {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// 按字/字节顺序转换寄存器值与大端字节序列 (转换是对称的, 可用于读取与写入)
+// src 与 dst 的长度相同且为 2 的倍数
+func orderRegs(dst, src []uint8, order WordOrder) {
+	n := len(src)
+	for i := 0; i < n; i += 2 {
+		j := i
+		if order.swapWords() {
+			j = n - 2 - i
+		}
+		if order.swapBytes() {
+			dst[j], dst[j+1] = src[i+1], src[i]
+		} else {
+			dst[j], dst[j+1] = src[i], src[i+1]
+		}
+	}
+}
+
+// 交换每个寄存器内的两个字节
+func swapBytes(b []uint8) {
+	for i := 0; i+1 < len(b); i += 2 {
+		b[i], b[i+1] = b[i+1], b[i]
+	}
+}
+
+func regsToUint32(input []uint8, order WordOrder) uint32 {
+	var b [4]uint8
+	orderRegs(b[:], input[:4], order)
+	return binary.BigEndian.Uint32(b[:])
+}
+
+func putRegsUint32(output []uint8, value uint32, order WordOrder) {
+	var b [4]uint8
+	binary.BigEndian.PutUint32(b[:], value)
+	orderRegs(output[:4], b[:], order)
+}
+
+func regsToUint64(input []uint8, order WordOrder) uint64 {
+	var b [8]uint8
+	orderRegs(b[:], input[:8], order)
+	return binary.BigEndian.Uint64(b[:])
+}
+
+func putRegsUint64(output []uint8, value uint64, order WordOrder) {
+	var b [8]uint8
+	binary.BigEndian.PutUint64(b[:], value)
+	orderRegs(output[:8], b[:], order)
+}
+
+// BCD 编码转换为整数, digits 为十进制位数
+// 返回值: 存在非十进制数字 (0xA~0xF) 时返回 false
+func bcdToUint(bcd uint32, digits int) (uint32, bool) {
+	var value uint32
+	for i := digits - 1; i >= 0; i-- {
+		nibble := (bcd >> (i * 4)) & 0x0F
+		if nibble > 9 {
+			return 0, false
+		}
+		value = value*10 + nibble
+	}
+	return value, true
+}
+
+// 整数转换为 BCD 编码, digits 为十进制位数
+// 返回值: 整数超过 digits 位时返回 false
+func uintToBcd(value uint32, digits int) (uint32, bool) {
+	var bcd uint32
+	for i := 0; i < digits; i++ {
+		bcd |= (value % 10) << (i * 4)
+		value /= 10
+	}
+	return bcd, value == 0
 }
@@ -0,0 +1,211 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestWordOrder(t *testing.T) {
+	tests := []struct {
+		order WordOrder
+		want  string // 0x11223344 / 0x1122334455667788 在报文中的字节
+		want8 string
+	}{
+		{OrderABCD, "11223344", "1122334455667788"},
+		{OrderCDAB, "33441122", "7788556633441122"},
+		{OrderBADC, "22114433", "2211443366558877"},
+		{OrderDCBA, "44332211", "8877665544332211"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order.String(), func(t *testing.T) {
+			var arg groArg
+			arg.SetUint32(0, 0x11223344, tt.order)
+			if got := arg.GetU8s(); !bytes.Equal(got, strToHex(tt.want)) {
+				t.Errorf("SetUint32() = %x, want %s", got, tt.want)
+			}
+			arg.SetU8s(nil)
+			arg.SetUint64(0, 0x1122334455667788, tt.order)
+			if got := arg.GetU8s(); !bytes.Equal(got, strToHex(tt.want8)) {
+				t.Errorf("SetUint64() = %x, want %s", got, tt.want8)
+			}
+		})
+	}
+
+	// CDAB 浮点数 1.0 = 0x3F800000
+	var arg groArg
+	arg.SetU8s(strToHex("0000 3F80"))
+	if got := arg.GetFloat32(0, OrderCDAB); got != 1 {
+		t.Fatalf("GetFloat32(CDAB) = %v, want 1", got)
+	}
+}
+
+func TestWordOrderRoundTrip(t *testing.T) {
+	orders := []WordOrder{OrderABCD, OrderCDAB, OrderBADC, OrderDCBA}
+
+	for _, order := range orders {
+		// 奇数寄存器偏移, 值跨越 4 字节边界
+		for _, reg := range []int{0, 1, 3} {
+			var arg groArg
+			arg.SetU16s([]uint16{0xAAAA}, binary.BigEndian)
+
+			arg.SetUint32(reg, 0xDEADBEEF, order)
+			if got := arg.GetUint32(reg, order); got != 0xDEADBEEF {
+				t.Errorf("%s@%d: Uint32 = %08X", order, reg, got)
+			}
+			arg.SetInt32(reg, -123456, order)
+			if got := arg.GetInt32(reg, order); got != -123456 {
+				t.Errorf("%s@%d: Int32 = %d", order, reg, got)
+			}
+			arg.SetFloat32(reg, -3.25, order)
+			if got := arg.GetFloat32(reg, order); got != -3.25 {
+				t.Errorf("%s@%d: Float32 = %v", order, reg, got)
+			}
+			arg.SetUint64(reg, 0x0102030405060708, order)
+			if got := arg.GetUint64(reg, order); got != 0x0102030405060708 {
+				t.Errorf("%s@%d: Uint64 = %016X", order, reg, got)
+			}
+			arg.SetInt64(reg, math.MinInt64+1, order)
+			if got := arg.GetInt64(reg, order); got != math.MinInt64+1 {
+				t.Errorf("%s@%d: Int64 = %d", order, reg, got)
+			}
+			arg.SetFloat64(reg, math.Pi, order)
+			if got := arg.GetFloat64(reg, order); got != math.Pi {
+				t.Errorf("%s@%d: Float64 = %v", order, reg, got)
+			}
+
+			// 写入时保留之前的寄存器值
+			if reg > 0 && arg.GetU16(0, binary.BigEndian) != 0xAAAA {
+				t.Errorf("%s@%d: register 0 overwritten: %04X", order, reg, arg.GetU16(0, binary.BigEndian))
+			}
+			if len(arg.GetU8s()) != reg*2+8 {
+				t.Errorf("%s@%d: length = %d", order, reg, len(arg.GetU8s()))
+			}
+		}
+	}
+
+	// 不同顺序之间的交叉读取
+	var arg groArg
+	arg.SetUint32(0, 0x11223344, OrderCDAB)
+	if got := arg.GetUint32(0, OrderABCD); got != 0x33441122 {
+		t.Fatalf("GetUint32(ABCD) = %08X, want 33441122", got)
+	}
+	if got := arg.GetUint32(0, OrderDCBA); got != 0x22114433 {
+		t.Fatalf("GetUint32(DCBA) = %08X, want 22114433", got)
+	}
+}
+
+func TestString(t *testing.T) {
+	var arg groArg
+	if err := arg.SetString(1, 3, "SN-1", PadSpace, false); err != nil {
+		t.Fatalf("Failed to set string: %v.\n", err)
+	}
+	if got := arg.GetU8s(); !bytes.Equal(got, []uint8("\x00\x00SN-1  ")) {
+		t.Fatalf("SetString() = %q", got)
+	}
+	if got := arg.GetString(1, 3, false); got != "SN-1" {
+		t.Fatalf("GetString() = %q, want SN-1", got)
+	}
+
+	// 字节交换: "ABC" -> "BA\x00C"
+	if err := arg.SetString(0, 2, "ABC", PadNul, true); err != nil {
+		t.Fatalf("Failed to set swapped string: %v.\n", err)
+	}
+	if got := arg.GetU8s()[:4]; !bytes.Equal(got, []uint8("BA\x00C")) {
+		t.Fatalf("SetString(swap) = %q", got)
+	}
+	if got := arg.GetString(0, 2, true); got != "ABC" {
+		t.Fatalf("GetString(swap) = %q, want ABC", got)
+	}
+	if err := arg.SetString(0, 2, "ABCDE", PadNul, false); !errors.Is(err, ErrResultRegValue) {
+		t.Fatalf("Expected ErrResultRegValue, got %v.\n", err)
+	}
+}
+
+func TestBCD(t *testing.T) {
+	var arg groArg
+	if err := arg.SetBCD16(0, 1234); err != nil {
+		t.Fatalf("Failed to set BCD16: %v.\n", err)
+	}
+	if err := arg.SetBCD32(1, 12345678, OrderCDAB); err != nil {
+		t.Fatalf("Failed to set BCD32: %v.\n", err)
+	}
+	if got := arg.GetU8s(); !bytes.Equal(got, strToHex("1234 5678 1234")) {
+		t.Fatalf("BCD registers = %x", got)
+	}
+	if v, err := arg.GetBCD16(0); err != nil || v != 1234 {
+		t.Fatalf("GetBCD16() = %d, %v", v, err)
+	}
+	if v, err := arg.GetBCD32(1, OrderCDAB); err != nil || v != 12345678 {
+		t.Fatalf("GetBCD32() = %d, %v", v, err)
+	}
+
+	if err := arg.SetBCD16(0, 10000); !errors.Is(err, ErrResultRegValue) {
+		t.Fatalf("Expected ErrResultRegValue for 10000, got %v.\n", err)
+	}
+	if err := arg.SetBCD32(0, 100000000, OrderABCD); !errors.Is(err, ErrResultRegValue) {
+		t.Fatalf("Expected ErrResultRegValue for 100000000, got %v.\n", err)
+	}
+	arg.SetU8s(strToHex("12A4"))
+	if _, err := arg.GetBCD16(0); !errors.Is(err, ErrResultRegValue) {
+		t.Fatalf("Expected ErrResultRegValue for 0x12A4, got %v.\n", err)
+	}
+}
+
+func TestBitField(t *testing.T) {
+	fields := []BitField{
+		{Name: "run", Bit: 0},
+		{Name: "mode", Bit: 4, Len: 3},
+		{Name: "alarm", Bit: 15, Len: 1},
+	}
+
+	var arg groArg
+	arg.SetU16s([]uint16{0x0F0F}, binary.BigEndian)
+	if err := arg.SetBitField(0, fields[1], 5, OrderABCD); err != nil {
+		t.Fatalf("Failed to set bit field: %v.\n", err)
+	}
+	if err := arg.SetBitField(0, fields[2], 1, OrderABCD); err != nil {
+		t.Fatalf("Failed to set bit field: %v.\n", err)
+	}
+	if got := arg.GetU16(0, binary.BigEndian); got != 0x8F5F {
+		t.Fatalf("Register = %04X, want 8F5F", got)
+	}
+	values, err := arg.GetBitFields(0, fields, OrderABCD)
+	if err != nil || values["run"] != 1 || values["mode"] != 5 || values["alarm"] != 1 {
+		t.Fatalf("GetBitFields() = %v", values)
+	}
+
+	if err := arg.SetBitField(0, fields[1], 8, OrderABCD); !errors.Is(err, ErrResultRegValue) {
+		t.Fatalf("Expected ErrResultRegValue for value, got %v.\n", err)
+	}
+	if err := arg.SetBitField(0, BitField{Bit: 14, Len: 3}, 0, OrderABCD); !errors.Is(err, ErrResultRegValue) {
+		t.Fatalf("Expected ErrResultRegValue for width, got %v.\n", err)
+	}
+	if _, err := arg.GetBitField(0, BitField{Bit: 14, Len: 3}, OrderABCD); !errors.Is(err, ErrResultRegValue) {
+		t.Fatalf("Expected ErrResultRegValue for get width, got %v.\n", err)
+	}
+	if _, err := arg.GetBitFields(0, []BitField{fields[0], {Name: "bad", Bit: 16}}, OrderABCD); !errors.Is(err, ErrResultRegValue) {
+		t.Fatalf("Expected ErrResultRegValue for get bit, got %v.\n", err)
+	}
+
+	// 字节交换: 寄存器 0x8F5F 的低字节为位 8~15
+	if got, _ := arg.GetBitField(0, fields[2], OrderBADC); got != 0 {
+		t.Fatalf("GetBitField(BADC) = %d, want 0", got)
+	}
+	if got, _ := arg.GetBitField(0, BitField{Bit: 8, Len: 8}, OrderDCBA); got != 0x5F {
+		t.Fatalf("GetBitField(DCBA) = %02X, want 5F", got)
+	}
+	if err := arg.SetBitField(0, fields[0], 0, OrderBADC); err != nil {
+		t.Fatalf("Failed to set swapped bit field: %v.\n", err)
+	}
+	if got := arg.GetU16(0, binary.BigEndian); got != 0x8E5F {
+		t.Fatalf("Register = %04X, want 8E5F", got)
+	}
+}
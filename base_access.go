@@ -11,14 +11,38 @@ type AccessFilter func(devid uint8, userdata any) bool
 // 检查请求的寄存器地址是否运行读/写
 type AccessCheck func(regaddr, reglen uint16, isRead bool, userdata any) bool
 
+// 读/写文件记录, 读取时将记录数据填充至 data, 写入时 data 为待写入的记录数据 (在校验整个报文后写入)
+// 返回值: true-成功, false-文件或记录不存在
+type AccessFile func(file, record uint16, data []uint8, isRead bool, userdata any) bool
+
+// 获取先进先出队列的内容 (队列指针地址), 队列不存在时返回 false
+type AccessFifo func(addr uint16, userdata any) ([]uint16, bool)
+
+// 获取设备标识对象的值, 对象不存在时返回 false
+type AccessDevIdent func(objid uint8, userdata any) ([]uint8, bool)
+
+// 检查角色是否允许使用功能码, 拒绝时回复非法功能码异常
+// role 为 TLS 客户端证书中的角色 (未认证或证书不含角色时为空)
+type AccessRole func(role string, funccode uint8, userdata any) bool
+
+// 检查角色是否允许读/写数据表的地址范围, 拒绝时回复非法数据地址异常
+// 在数据表的检查函数 (如 CheckHold) 通过后调用, role 同 AccessRole
+type AccessRoleCheck func(role string, table Table, regaddr, reglen uint16, isRead bool, userdata any) bool
+
 // / Modbus-数据访问控制器
 type groAccess struct {
-	UserData      any          // 用户数据
-	FilterDevID   AccessFilter // 过滤请求的设备ID
-	CheckCoil     AccessCheck  // 检查函数-线圈状态
-	CheckDiscrete AccessCheck  // 检查函数-离散量输入
-	CheckHold     AccessCheck  // 检查函数-保持寄存器
-	CheckInput    AccessCheck  // 检查函数-输入寄存器
+	UserData      any             // 用户数据
+	FilterDevID   AccessFilter    // 过滤请求的设备ID
+	CheckCoil     AccessCheck     // 检查函数-线圈状态
+	CheckDiscrete AccessCheck     // 检查函数-离散量输入
+	CheckHold     AccessCheck     // 检查函数-保持寄存器
+	CheckInput    AccessCheck     // 检查函数-输入寄存器
+	FileStore     AccessFile      // 读写函数-文件记录
+	ReadFifo      AccessFifo      // 获取函数-先进先出队列
+	DevIdent      AccessDevIdent  // 获取函数-设备标识对象
+	Role          string          // 已认证的角色 (Modbus/TCP Security)
+	CheckRole     AccessRole      // 检查函数-角色授权
+	CheckRoleAddr AccessRoleCheck // 检查函数-角色的地址授权
 }
 
 func (a *groAccess) Reset() {
@@ -28,6 +52,12 @@ func (a *groAccess) Reset() {
 	a.CheckDiscrete = nil
 	a.CheckHold = nil
 	a.CheckInput = nil
+	a.FileStore = nil
+	a.ReadFifo = nil
+	a.DevIdent = nil
+	a.Role = ""
+	a.CheckRole = nil
+	a.CheckRoleAddr = nil
 }
 
 func (a *groAccess) SetUserData(UserData any) {
@@ -53,3 +83,51 @@ func (a *groAccess) SetCheckHold(CheckHold AccessCheck) {
 func (a *groAccess) SetCheckInput(CheckInput AccessCheck) {
 	a.CheckInput = CheckInput
 }
+
+func (a *groAccess) SetFileStore(FileStore AccessFile) {
+	a.FileStore = FileStore
+}
+
+func (a *groAccess) SetReadFifo(ReadFifo AccessFifo) {
+	a.ReadFifo = ReadFifo
+}
+
+func (a *groAccess) SetDevIdent(DevIdent AccessDevIdent) {
+	a.DevIdent = DevIdent
+}
+
+// 设置 已认证的角色, 由 CheckRole/CheckRoleAddr 依据角色限制可使用的功能码与地址
+func (a *groAccess) SetRole(Role string) {
+	a.Role = Role
+}
+
+func (a *groAccess) SetCheckRole(CheckRole AccessRole) {
+	a.CheckRole = CheckRole
+}
+
+func (a *groAccess) SetCheckRoleAddr(CheckRoleAddr AccessRoleCheck) {
+	a.CheckRoleAddr = CheckRoleAddr
+}
+
+func (a *groAccess) GetRole() string {
+	return a.Role
+}
+
+// 检查数据表的地址范围是否允许读/写: 数据表的检查函数与角色的地址授权均需通过
+func (a *groAccess) check(table Table, regaddr, reglen uint16, isRead bool) bool {
+	var check AccessCheck
+	switch table {
+	case TableCoil:
+		check = a.CheckCoil
+	case TableDiscrete:
+		check = a.CheckDiscrete
+	case TableHold:
+		check = a.CheckHold
+	case TableInput:
+		check = a.CheckInput
+	}
+	if check == nil || !check(regaddr, reglen, isRead, a.UserData) {
+		return false
+	}
+	return a.CheckRoleAddr == nil || a.CheckRoleAddr(a.Role, table, regaddr, reglen, isRead, a.UserData)
+}
@@ -7,14 +7,78 @@ package gromb
 
 import (
 	"encoding/binary"
+	"math"
+	"strings"
 )
 
+// 设备标识对象
+type DevIdObject struct {
+	Id    uint8   // 对象ID
+	Value []uint8 // 对象值
+}
+
+// 文件记录 (读/写文件记录子请求)
+type FileRecord struct {
+	File   uint16  // 文件号
+	Record uint16  // 起始记录号 (0x0000 ~ 0x270F)
+	Length uint16  // 记录长度 (寄存器数量)
+	Data   []uint8 // 记录数据 (Length * 2 字节)
+}
+
+// 寄存器宽度范围 (Enron Modbus)
+// 范围内的每个寄存器占 Width 字节, 影响读/写多个保持寄存器的字节数与数量上限
+type RegWidth struct {
+	Start uint16 // 起始寄存器地址
+	End   uint16 // 结束寄存器地址 (包含)
+	Width uint8  // 寄存器字节数 (2 或 4)
+}
+
+// Enron Modbus 常用的 32 位寄存器范围 (5000~5999 整数, 7000~7999 浮点数)
+var EnronRegWidths = []RegWidth{
+	{Start: 5000, End: 5999, Width: 4},
+	{Start: 7000, End: 7999, Width: 4},
+}
+
+// 寄存器中的位字段 (如状态字中的各个标志)
+type BitField struct {
+	Name string // 名称
+	Bit  uint8  // 起始位 (0~15)
+	Len  uint8  // 宽度 (1~16, 0 视为 1)
+}
+
+// 位字段的掩码 (未移位)
+func (f BitField) mask() uint16 {
+	n := max(f.Len, 1)
+	return uint16(1<<n - 1)
+}
+
+// 设备标识参数 (读设备标识)
+type groIdent struct {
+	code       uint8         // 读设备标识码
+	objid      uint8         // 请求的对象ID
+	conformity uint8         // 一致性等级
+	more       bool          // 是否有后续对象
+	nextobjid  uint8         // 下一个对象ID
+	objects    []DevIdObject // 对象列表
+}
+
 // Modbus 处理套件-处理值
 type groArg struct {
-	funccode uint8   // 功能码
-	regaddr  uint16  // 寄存器地址
-	reglen   uint16  // 寄存器长度
-	all      []uint8 // 寄存器值
+	funccode uint8        // 功能码
+	regaddr  uint16       // 寄存器地址
+	reglen   uint16       // 寄存器长度
+	wregaddr uint16       // 写寄存器地址 (读写多个保持寄存器)
+	wreglen  uint16       // 写寄存器长度 (读写多个保持寄存器)
+	andmask  uint16       // 与屏蔽码 (屏蔽写保持寄存器)
+	ormask   uint16       // 或屏蔽码 (屏蔽写保持寄存器)
+	ident    groIdent     // 设备标识参数 (读设备标识)
+	records  []FileRecord // 文件记录子请求 (读/写文件记录)
+	subfunc  uint16       // 子功能码 (诊断)
+	raw      bool         // 原始 PDU 模式
+	rawpdu   []uint8      // 原始 PDU (含功能码)
+	widths   []RegWidth   // 寄存器宽度范围 (Enron Modbus)
+	all      []uint8      // 寄存器值
+	buf      []uint8      // 寄存器值缓冲区 (复用已分配的空间)
 }
 
 func (a *groArg) Init(funccode uint8, regaddr, reglen uint16) {
@@ -26,6 +90,9 @@ func (a *groArg) Init(funccode uint8, regaddr, reglen uint16) {
 
 func (a *groArg) Reset() {
 	a.all = nil
+	a.raw = false
+	a.rawpdu = nil
+	a.widths = nil
 }
 
 func (a *groArg) SetFuncCode(funccode uint8) {
@@ -40,6 +107,67 @@ func (a *groArg) SetRegLen(reglen uint16) {
 	a.reglen = reglen
 }
 
+// 设置 写寄存器地址 (读写多个保持寄存器, 读寄存器地址使用 SetRegAddr)
+func (a *groArg) SetWriteRegAddr(regaddr uint16) {
+	a.wregaddr = regaddr
+}
+
+// 设置 写寄存器长度 (读写多个保持寄存器, 读寄存器长度使用 SetRegLen)
+func (a *groArg) SetWriteRegLen(reglen uint16) {
+	a.wreglen = reglen
+}
+
+// 设置 与/或屏蔽码 (屏蔽写保持寄存器)
+func (a *groArg) SetMask(andmask, ormask uint16) {
+	a.andmask = andmask
+	a.ormask = ormask
+}
+
+// 设置 读设备标识码与起始对象ID (读设备标识)
+func (a *groArg) SetDevIdent(code, objid uint8) {
+	a.ident.code = code
+	a.ident.objid = objid
+}
+
+// 设置 子功能码 (诊断)
+func (a *groArg) SetSubFunc(subfunc uint16) {
+	a.subfunc = subfunc
+}
+
+// 设置 原始 PDU 模式
+// 启用后封装时直接使用 SetRawPdu 设置的 PDU, 仅添加 ADU 帧 (设备标识/CRC/LRC/MBAP);
+// 解析时校验并去除 ADU 帧, 通过 GetRawPdu 获取 PDU
+func (a *groArg) SetRawMode(raw bool) {
+	a.raw = raw
+}
+
+// 设置 原始 PDU (含功能码, 原始 PDU 模式)
+func (a *groArg) SetRawPdu(pdu []uint8) {
+	a.rawpdu = pdu
+}
+
+// 设置 寄存器宽度范围 (Enron Modbus, 如 EnronRegWidths)
+// 范围之外的寄存器为 2 字节; 宽度无效 (非 2 或 4) 或范围重叠时返回 ErrResultRegWidth
+func (a *groArg) SetRegWidths(widths []RegWidth) error {
+	for i, w := range widths {
+		if (w.Width != 2 && w.Width != 4) || w.Start > w.End {
+			return ErrResultRegWidth
+		}
+		for _, o := range widths[:i] {
+			if w.Start <= o.End && o.Start <= w.End {
+				return ErrResultRegWidth
+			}
+		}
+	}
+	a.widths = append(a.widths[:0], widths...)
+	return nil
+}
+
+// 设置 文件记录子请求 (读/写文件记录)
+func (a *groArg) SetFileRecords(records []FileRecord) {
+	a.records = append(a.records[:0], records...)
+}
+
 func (a *groArg) GetFuncCode() uint8 {
 	return a.funccode
 }
@@ -52,6 +180,93 @@ func (a *groArg) GetRegLen() uint16 {
 	return a.reglen
 }
 
+func (a *groArg) GetWriteRegAddr() uint16 {
+	return a.wregaddr
+}
+
+func (a *groArg) GetWriteRegLen() uint16 {
+	return a.wreglen
+}
+
+func (a *groArg) GetAndMask() uint16 {
+	return a.andmask
+}
+
+func (a *groArg) GetOrMask() uint16 {
+	return a.ormask
+}
+
+// 对寄存器当前值应用屏蔽码, 返回写入的值 (屏蔽写保持寄存器)
+// 结果 = (当前值 AND 与屏蔽码) OR (或屏蔽码 AND (NOT 与屏蔽码))
+func (a *groArg) ApplyMask(value uint16) uint16 {
+	return (value & a.andmask) | (a.ormask &^ a.andmask)
+}
+
+func (a *groArg) GetDevIdCode() uint8 {
+	return a.ident.code
+}
+
+func (a *groArg) GetObjectId() uint8 {
+	return a.ident.objid
+}
+
+// 获取 一致性等级 (读设备标识响应)
+func (a *groArg) GetConformity() uint8 {
+	return a.ident.conformity
+}
+
+// 获取 是否有后续对象及下一个对象ID (读设备标识响应)
+func (a *groArg) GetMoreFollows() (bool, uint8) {
+	return a.ident.more, a.ident.nextobjid
+}
+
+// 获取 设备标识对象列表 (读设备标识响应)
+// 对象值引用报文缓冲区, 需要保存时应复制
+func (a *groArg) GetObjects() []DevIdObject {
+	return a.ident.objects
+}
+
+func (a *groArg) GetSubFunc() uint16 {
+	return a.subfunc
+}
+
+func (a *groArg) IsRawMode() bool {
+	return a.raw
+}
+
+// 获取 原始 PDU (原始 PDU 模式)
+// 解析得到的 PDU 引用报文缓冲区, 需要保存时应复制
+func (a *groArg) GetRawPdu() []uint8 {
+	return a.rawpdu
+}
+
+// 获取 文件记录子请求 (读/写文件记录)
+// 记录数据引用报文缓冲区, 需要保存时应复制
+func (a *groArg) GetFileRecords() []FileRecord {
+	return a.records
+}
+
+func (a *groArg) GetRegWidths() []RegWidth {
+	return a.widths
+}
+
+// 获取 寄存器字节数 (Enron Modbus)
+// 返回值: 寄存器跨越不同宽度的范围时返回 false
+func (a *groArg) regWidth(regaddr, reglen uint16) (uint16, bool) {
+	width := uint16(2)
+	last := uint32(regaddr) + uint32(reglen) - 1
+	for _, w := range a.widths {
+		if uint32(w.Start) > last || w.End < regaddr {
+			continue
+		}
+		if w.Start > regaddr || uint32(w.End) < last {
+			return 0, false
+		}
+		width = uint16(w.Width)
+	}
+	return width, true
+}
+
 func (a *groArg) GetFuncCodeString() string {
 	return FuncCodeToString(a.funccode)
 }
@@ -84,10 +299,20 @@ func (a *groArg) GetFloat32s(order binary.ByteOrder) []float32 {
 	return bytesToFloat32s(a.all, order)
 }
 
+// 获取长度为 n 的寄存器值缓冲区
+// 注意: 复用内部缓冲区, 之前通过 GetU8s 获取的切片可能被覆盖
+func (a *groArg) alloc(n int) []uint8 {
+	if cap(a.buf) < n {
+		a.buf = make([]uint8, n)
+	}
+	a.all = a.buf[:n]
+	return a.all
+}
+
 func (a *groArg) SetBits(data []bool) {
 	sum := len(data)
 	number := (sum + 7) / 8
-	a.all = make([]uint8, number)
+	a.alloc(number)
 
 	s := 0
 	for n := 0; s < sum; n++ {
@@ -125,9 +350,191 @@ func (a *groArg) SetU8s(data []uint8) {
 }
 
 func (a *groArg) SetU16s(data []uint16, order binary.ByteOrder) {
-	a.all = bytesFromUint16s(data, order)
+	putUint16s(a.alloc(len(data)*2), data, order)
 }
 
 func (a *groArg) SetFloat32s(data []float32, order binary.ByteOrder) {
-	a.all = bytesFromFloat32s(data, order)
+	putFloat32s(a.alloc(len(data)*4), data, order)
+}
+
+// 扩展寄存器值至 n 字节 (保留原有的值, 新增部分为 0)
+// 寄存器值不在内部缓冲区时 (如 SetU8s 或解析报文后引用外部内存) 先复制到内部缓冲区, 避免修改外部内存
+func (a *groArg) grow(n int) []uint8 {
+	owned := len(a.all) == 0 || cap(a.buf) > 0 && &a.all[0] == &a.buf[0]
+	if len(a.all) >= n && owned {
+		return a.all
+	}
+	n = max(n, len(a.all))
+	if cap(a.buf) < n {
+		buf := make([]uint8, n)
+		copy(buf, a.all)
+		a.buf = buf
+	} else {
+		copy(a.buf[:n], a.all)
+		clear(a.buf[len(a.all):n])
+	}
+	a.all = a.buf[:n]
+	return a.all
+}
+
+// 多寄存器值的读写 (reg 为寄存器偏移, 即第 reg 个寄存器, 不要求对齐)
+// 读取时超出寄存器值范围将 panic, 写入时自动扩展寄存器值
+
+func (a *groArg) GetUint32(reg int, order WordOrder) uint32 {
+	return regsToUint32(a.all[reg*2:reg*2+4], order)
+}
+
+func (a *groArg) GetInt32(reg int, order WordOrder) int32 {
+	return int32(a.GetUint32(reg, order))
+}
+
+func (a *groArg) GetFloat32(reg int, order WordOrder) float32 {
+	return math.Float32frombits(a.GetUint32(reg, order))
+}
+
+func (a *groArg) GetUint64(reg int, order WordOrder) uint64 {
+	return regsToUint64(a.all[reg*2:reg*2+8], order)
+}
+
+func (a *groArg) GetInt64(reg int, order WordOrder) int64 {
+	return int64(a.GetUint64(reg, order))
+}
+
+func (a *groArg) GetFloat64(reg int, order WordOrder) float64 {
+	return math.Float64frombits(a.GetUint64(reg, order))
+}
+
+func (a *groArg) SetUint32(reg int, value uint32, order WordOrder) {
+	putRegsUint32(a.grow(reg*2 + 4)[reg*2:], value, order)
+}
+
+func (a *groArg) SetInt32(reg int, value int32, order WordOrder) {
+	a.SetUint32(reg, uint32(value), order)
+}
+
+func (a *groArg) SetFloat32(reg int, value float32, order WordOrder) {
+	a.SetUint32(reg, math.Float32bits(value), order)
+}
+
+func (a *groArg) SetUint64(reg int, value uint64, order WordOrder) {
+	putRegsUint64(a.grow(reg*2 + 8)[reg*2:], value, order)
+}
+
+func (a *groArg) SetInt64(reg int, value int64, order WordOrder) {
+	a.SetUint64(reg, uint64(value), order)
+}
+
+func (a *groArg) SetFloat64(reg int, value float64, order WordOrder) {
+	a.SetUint64(reg, math.Float64bits(value), order)
+}
+
+// 获取 定长字符串 (每个寄存器 2 个字符, n 为寄存器数量)
+// swap 为 true 时交换寄存器内的字节 (低字节在前), 去除尾部的 NUL 与空格填充
+func (a *groArg) GetString(reg, n int, swap bool) string {
+	b := make([]uint8, n*2)
+	copy(b, a.all[reg*2:reg*2+n*2])
+	if swap {
+		swapBytes(b)
+	}
+	return strings.TrimRight(string(b), "\x00 ")
+}
+
+// 设置 定长字符串 (n 为寄存器数量), 不足部分以 pad (PadNul/PadSpace) 填充
+// 返回值: 字符串超过 n*2 字节时返回 ErrResultRegValue
+func (a *groArg) SetString(reg, n int, s string, pad uint8, swap bool) error {
+	if len(s) > n*2 {
+		return ErrResultRegValue
+	}
+	b := a.grow(reg*2 + n*2)[reg*2 : reg*2+n*2]
+	copy(b, s)
+	for i := len(s); i < len(b); i++ {
+		b[i] = pad
+	}
+	if swap {
+		swapBytes(b)
+	}
+	return nil
+}
+
+// 获取 4 位 BCD 码 (单个寄存器, 如 0x1234 表示 1234)
+// 返回值: 存在非十进制数字时返回 ErrResultRegValue
+func (a *groArg) GetBCD16(reg int) (uint16, error) {
+	value, ok := bcdToUint(uint32(a.GetU16(reg*2, binary.BigEndian)), 4)
+	if !ok {
+		return 0, ErrResultRegValue
+	}
+	return uint16(value), nil
+}
+
+// 获取 8 位 BCD 码 (两个寄存器, 依据 order 组合为 32 位)
+// 返回值: 存在非十进制数字时返回 ErrResultRegValue
+func (a *groArg) GetBCD32(reg int, order WordOrder) (uint32, error) {
+	value, ok := bcdToUint(a.GetUint32(reg, order), 8)
+	if !ok {
+		return 0, ErrResultRegValue
+	}
+	return value, nil
+}
+
+// 设置 4 位 BCD 码
+// 返回值: 值超过 9999 时返回 ErrResultRegValue
+func (a *groArg) SetBCD16(reg int, value uint16) error {
+	bcd, ok := uintToBcd(uint32(value), 4)
+	if !ok {
+		return ErrResultRegValue
+	}
+	binary.BigEndian.PutUint16(a.grow(reg*2 + 2)[reg*2:], uint16(bcd))
+	return nil
+}
+
+// 设置 8 位 BCD 码
+// 返回值: 值超过 99999999 时返回 ErrResultRegValue
+func (a *groArg) SetBCD32(reg int, value uint32, order WordOrder) error {
+	bcd, ok := uintToBcd(value, 8)
+	if !ok {
+		return ErrResultRegValue
+	}
+	a.SetUint32(reg, bcd, order)
+	return nil
+}
+
+// 位字段是否位于 16 位寄存器之内
+func (f BitField) valid() bool {
+	return int(f.Bit)+int(max(f.Len, 1)) <= 16
+}
+
+// 获取 寄存器中的位字段 (order 为字节顺序, BADC/DCBA 交换寄存器的高低字节)
+// 返回值: 位字段超出 16 位时返回 ErrResultRegValue
+func (a *groArg) GetBitField(reg int, field BitField, order WordOrder) (uint16, error) {
+	if !field.valid() {
+		return 0, ErrResultRegValue
+	}
+	return a.GetU16(reg*2, order.byteOrder()) >> field.Bit & field.mask(), nil
+}
+
+// 获取 寄存器中的多个位字段 (名称 -> 值)
+// 返回值: 任一位字段超出 16 位时返回 ErrResultRegValue
+func (a *groArg) GetBitFields(reg int, fields []BitField, order WordOrder) (map[string]uint16, error) {
+	values := make(map[string]uint16, len(fields))
+	for _, f := range fields {
+		value, err := a.GetBitField(reg, f, order)
+		if err != nil {
+			return nil, err
+		}
+		values[f.Name] = value
+	}
+	return values, nil
+}
+
+// 设置 寄存器中的位字段, 寄存器的其他位保持不变
+// 返回值: 位字段超出 16 位或值超出位字段宽度时返回 ErrResultRegValue
+func (a *groArg) SetBitField(reg int, field BitField, value uint16, order WordOrder) error {
+	mask := field.mask()
+	if !field.valid() || value&^mask != 0 {
+		return ErrResultRegValue
+	}
+	b := a.grow(reg*2 + 2)[reg*2:]
+	old := order.byteOrder().Uint16(b)
+	order.byteOrder().PutUint16(b, old&^(mask<<field.Bit)|value<<field.Bit)
+	return nil
 }
@@ -24,13 +24,14 @@ import (
 
 // 缓冲盒子
 type groBox struct {
-	buffer *[]uint8 // 缓冲区
-	last   uint16   // 已用空间
-	max    uint16   // 最大空间 (默认为 1024)
+	buffer []uint8 // 缓冲区
+	last   uint16  // 已用空间
+	max    uint16  // 最大空间 (默认为 1024)
 }
 
 // 初始化
-func (b *groBox) Init(buffer *[]uint8, max uint16) {
+// 封装时报文追加到 buffer 尾部, 通过 Bytes 获取追加后的缓冲区
+func (b *groBox) Init(buffer []uint8, max uint16) {
 	b.buffer = buffer
 	b.last = 0
 	b.max = max
@@ -43,7 +44,7 @@ func (b *groBox) Reset() {
 
 // 清空元素
 func (b *groBox) Clear() {
-	(*b.buffer) = make([]uint8, 0)
+	b.buffer = b.buffer[:0]
 	b.last = 0
 	b.max = 0
 }
@@ -80,92 +81,121 @@ func (b *groBox) SubLast(n uint16) {
 
 // // 获取 使用空间
 // func (b *groBox) LastSize() uint16 {
-// 	return uint16(len(*b.buffer)) - b.last
+// 	return uint16(len(b.buffer)) - b.last
 // }
 
 // 获取 使用空间
 func (b *groBox) ThisSize() uint16 {
-	return uint16(len(*b.buffer)) - b.last
+	size := len(b.buffer) - int(b.last)
+	if size < 0 {
+		return 0
+	}
+	if size > 0xFFFF {
+		return 0xFFFF
+	}
+	return uint16(size)
 }
 
 // 获取 总长度
 func (b *groBox) Size() uint16 {
-	return uint16(len(*b.buffer))
+	return uint16(len(b.buffer))
 }
 
 // 获取 可用空间
 func (b *groBox) Available() uint16 {
-	return b.max - uint16(len(*b.buffer)) - b.last
+	return b.max - uint16(len(b.buffer)) - b.last
+}
+
+// 获取 缓冲区 (包含本次封装追加的内容)
+func (b *groBox) Bytes() []uint8 {
+	return b.buffer
 }
 
 // 获取 总缓冲区
 func (b *groBox) GetBuffer(start uint16, end uint16) []uint8 {
-	return (*b.buffer)[start:end]
+	return b.buffer[start:end]
 }
 
 // 获取 缓冲区
 func (b *groBox) GetThisBuffer(start uint16, end uint16) []uint8 {
-	return (*b.buffer)[b.last+start : b.last+end]
+	return b.buffer[b.last+start : b.last+end]
 }
 
 // 获取一个 uint8
 func (b *groBox) GetU8(offset uint16) uint8 {
-	return (*b.buffer)[b.last+offset]
+	return b.buffer[b.last+offset]
 }
 
 // 获取一个 uint16
 func (b *groBox) GetU16(offset uint16, order binary.ByteOrder) uint16 {
-	return order.Uint16((*b.buffer)[b.last+offset : b.last+offset+2])
+	return order.Uint16(b.buffer[b.last+offset : b.last+offset+2])
 }
 
 // 获取多个 uint8
 func (b *groBox) GetU8s(offset uint16, end uint16) []uint8 {
-	return (*b.buffer)[b.last+offset : b.last+end]
+	return b.buffer[b.last+offset : b.last+end]
 }
 
 // 获取多个 uint16
 func (b *groBox) GetU16s(offset uint16, end uint16, order binary.ByteOrder) []uint16 {
-	return bytesToUint16s((*b.buffer)[b.last+offset:b.last+end*2], order)
+	return bytesToUint16s(b.buffer[b.last+offset:b.last+end*2], order)
 }
 
 // 设置一个 uint8
 func (b *groBox) SetU8(offset uint16, value uint8) {
-	(*b.buffer)[b.last+offset] = value
+	b.buffer[b.last+offset] = value
 }
 
 // 设置一个 uint16
 func (b *groBox) SetU16(offset uint16, value uint16, order binary.ByteOrder) {
-	order.PutUint16((*b.buffer)[b.last+offset:b.last+offset+2], value)
+	order.PutUint16(b.buffer[b.last+offset:b.last+offset+2], value)
 }
 
 // 尾部插入 uint8
 func (b *groBox) PutU8(value uint8) {
-	(*b.buffer) = append((*b.buffer), value)
+	b.buffer = append(b.buffer, value)
 }
 
 // 尾部插入 uint16
 func (b *groBox) PutU16(value uint16, order binary.ByteOrder) {
-	(*b.buffer) = append((*b.buffer), byteFromUint16(value, order)...)
+	n := len(b.buffer)
+	b.buffer = append(b.buffer, 0, 0)
+	order.PutUint16(b.buffer[n:n+2], value)
 }
 
 // 尾部插入 []uint8
 func (b *groBox) PutU8s(arg []uint8) {
-	(*b.buffer) = append((*b.buffer), arg...)
+	b.buffer = append(b.buffer, arg...)
 }
 
 // 尾部插入 []uint16
 func (b *groBox) PutU16s(arg []uint16, order binary.ByteOrder) {
-	(*b.buffer) = append((*b.buffer), bytesFromUint16s(arg, order)...)
+	for _, v := range arg {
+		b.PutU16(v, order)
+	}
+}
+
+// 将 [已用空间+offset, 末尾) 的内容原地转换为十六进制字符
+func (b *groBox) ExpandHex(offset uint16) {
+	start := int(b.last + offset)
+	n := len(b.buffer) - start
+	b.buffer = append(b.buffer, make([]uint8, n)...)
+
+	// 从尾部开始转换, 避免覆盖未转换的内容
+	for i := n - 1; i >= 0; i-- {
+		v := b.buffer[start+i]
+		b.buffer[start+i*2] = hextable[v>>4]
+		b.buffer[start+i*2+1] = hextable[v&0x0f]
+	}
 }
 
 // 设置 []uint8
 func (b *groBox) SetU8s(arg []uint8) {
-	(*b.buffer) = make([]uint8, len(arg))
-	copy((*b.buffer), arg)
+	b.buffer = append(b.buffer[:0], arg...)
 }
 
 // 设置 []uint16
 func (b *groBox) SetU16s(arg []uint16, order binary.ByteOrder) {
-	(*b.buffer) = make([]uint8, len(arg)*2)
-	copy((*b.buffer), bytesFromUint16s(arg, order))
+	b.buffer = b.buffer[:0]
+	b.PutU16s(arg, order)
 }
@@ -12,6 +12,8 @@ const (
 	ProtocolRTU = iota
 	ProtocolAscii
 	ProtocolTCP
+	ProtocolRtuOverTcp   // RTU 帧透传 (TCP 连接, 无 MBAP 头)
+	ProtocolAsciiOverTcp // Ascii 帧透传 (TCP 连接, 无 MBAP 头)
 )
 
 func ProtocolToString(p uint8) string {
@@ -22,20 +24,38 @@ func ProtocolToString(p uint8) string {
 		return "modbus Ascii"
 	case ProtocolTCP:
 		return "modbus TCP"
+	case ProtocolRtuOverTcp:
+		return "modbus RTU over TCP"
+	case ProtocolAsciiOverTcp:
+		return "modbus Ascii over TCP"
 	}
 	return "unknown protocol"
 }
 
+// 广播地址 (串行链路), 从机执行写请求但不回复
+const DevIdBroadcast = 0x00
+
 // Modbus 功能码 (Modbus Function Code)
 const (
-	FuncCodeReadCoil     = 0x01 // 读线圈
-	FuncCodeReadDiscrete = 0x02 // 读离散量输入
-	FuncCodeReadHold     = 0x03 // 读保持寄存器
-	FuncCodeReadInput    = 0x04 // 读输入寄存器
-	FuncCodeWriteCoil    = 0x05 // 写单个线圈寄存器
-	FuncCodeWriteHold    = 0x06 // 写单个保持寄存器
-	FuncCodeWriteCoils   = 0x0F // 写多个线圈
-	FuncCodeWriteHolds   = 0x10 // 写多个保持寄存器
+	FuncCodeReadCoil            = 0x01 // 读线圈
+	FuncCodeReadDiscrete        = 0x02 // 读离散量输入
+	FuncCodeReadHold            = 0x03 // 读保持寄存器
+	FuncCodeReadInput           = 0x04 // 读输入寄存器
+	FuncCodeWriteCoil           = 0x05 // 写单个线圈寄存器
+	FuncCodeWriteHold           = 0x06 // 写单个保持寄存器
+	FuncCodeReadExcepStatus     = 0x07 // 读异常状态 (串行链路)
+	FuncCodeDiag                = 0x08 // 诊断 (串行链路)
+	FuncCodeGetCommEventCounter = 0x0B // 获取通信事件计数 (串行链路)
+	FuncCodeGetCommEventLog     = 0x0C // 获取通信事件日志 (串行链路)
+	FuncCodeWriteCoils          = 0x0F // 写多个线圈
+	FuncCodeWriteHolds          = 0x10 // 写多个保持寄存器
+	FuncCodeReportServerId      = 0x11 // 报告从机标识 (串行链路)
+	FuncCodeReadFile            = 0x14 // 读文件记录
+	FuncCodeWriteFile           = 0x15 // 写文件记录
+	FuncCodeMaskWriteHold       = 0x16 // 屏蔽写保持寄存器
+	FuncCodeReadWriteHolds      = 0x17 // 读写多个保持寄存器
+	FuncCodeReadFifo            = 0x18 // 读先进先出队列
+	FuncCodeMei                 = 0x2B // 封装接口传输 (MEI)
 )
 
 func FuncCodeToString(b uint8) string {
@@ -52,15 +72,183 @@ func FuncCodeToString(b uint8) string {
 		return "write coil"
 	case FuncCodeWriteHold:
 		return "write hold"
+	case FuncCodeReadExcepStatus:
+		return "read exception status"
+	case FuncCodeDiag:
+		return "diagnostics"
+	case FuncCodeGetCommEventCounter:
+		return "get comm event counter"
+	case FuncCodeGetCommEventLog:
+		return "get comm event log"
 	case FuncCodeWriteCoils:
 		return "write coils"
 	case FuncCodeWriteHolds:
 		return "write holds"
+	case FuncCodeReportServerId:
+		return "report server id"
+	case FuncCodeReadFile:
+		return "read file"
+	case FuncCodeWriteFile:
+		return "write file"
+	case FuncCodeMaskWriteHold:
+		return "mask write hold"
+	case FuncCodeReadWriteHolds:
+		return "read write holds"
+	case FuncCodeReadFifo:
+		return "read fifo"
+	case FuncCodeMei:
+		return "mei transport"
 	default:
+		// 自定义功能码
+		if codec := lookupFuncCode(b); codec != nil && codec.Name != "" {
+			return codec.Name
+		}
 		return "unknown function code"
 	}
 }
 
+// 诊断子功能码 (Diagnostics Sub-function Code)
+const (
+	DiagReturnQueryData       = 0x00 // 返回询问数据
+	DiagRestartComm           = 0x01 // 重启通信选项 (数据 0xFF00 同时清除事件日志)
+	DiagReturnRegister        = 0x02 // 返回诊断寄存器
+	DiagChangeAsciiDelimiter  = 0x03 // 修改 Ascii 输入结束符
+	DiagForceListenOnly       = 0x04 // 强制只听模式 (无响应)
+	DiagClearCounters         = 0x0A // 清除计数器与诊断寄存器
+	DiagBusMessageCount       = 0x0B // 返回总线报文计数
+	DiagBusCommErrorCount     = 0x0C // 返回总线通信错误计数
+	DiagBusExcepErrorCount    = 0x0D // 返回总线异常响应计数
+	DiagServerMessageCount    = 0x0E // 返回从机报文计数
+	DiagServerNoResponseCount = 0x0F // 返回从机无响应计数
+	DiagServerNakCount        = 0x10 // 返回从机否定应答计数
+	DiagServerBusyCount       = 0x11 // 返回从机忙计数
+	DiagBusCharOverrunCount   = 0x12 // 返回总线字符溢出计数
+	DiagClearOverrunCounter   = 0x14 // 清除字符溢出计数
+)
+
+// 通信事件 (Comm Event Log)
+const (
+	EventRecv             = 0x80 // 接收事件
+	EventRecvCommError    = 0x02 // 接收事件-通信错误
+	EventRecvCharOverrun  = 0x10 // 接收事件-字符溢出
+	EventRecvListenOnly   = 0x20 // 接收事件-处于只听模式
+	EventRecvBroadcast    = 0x40 // 接收事件-广播报文
+	EventSend             = 0x40 // 发送事件
+	EventSendReadExcep    = 0x01 // 发送事件-异常响应 (异常码 1~3)
+	EventSendAbortExcep   = 0x02 // 发送事件-从机故障 (异常码 4)
+	EventSendBusyExcep    = 0x04 // 发送事件-从机忙 (异常码 5~6)
+	EventSendNakExcep     = 0x08 // 发送事件-否定应答 (异常码 7)
+	EventSendWriteTimeout = 0x10 // 发送事件-写超时
+	EventSendListenOnly   = 0x20 // 发送事件-处于只听模式
+	EventListenOnly       = 0x04 // 进入只听模式
+	EventRestart          = 0x00 // 重启通信
+)
+
+// Modbus 封装接口类型 (MEI Type)
+const (
+	MeiReadDevIdent = 0x0E // 读设备标识
+)
+
+// 读设备标识码 (Read Device ID Code)
+const (
+	DevIdBasic    = 0x01 // 基本设备标识 (流访问)
+	DevIdRegular  = 0x02 // 常规设备标识 (流访问)
+	DevIdExtended = 0x03 // 扩展设备标识 (流访问)
+	DevIdSpecific = 0x04 // 单个对象 (单独访问)
+)
+
+// 设备标识对象ID (Device Identification Object Id)
+const (
+	ObjVendorName          = 0x00 // 厂商名称
+	ObjProductCode         = 0x01 // 产品代码
+	ObjMajorMinorRevision  = 0x02 // 版本号
+	ObjVendorUrl           = 0x03 // 厂商网址
+	ObjProductName         = 0x04 // 产品名称
+	ObjModelName           = 0x05 // 型号名称
+	ObjUserApplicationName = 0x06 // 用户应用名称
+)
+
+// 多寄存器值的字/字节顺序 (Word Order)
+// 以 32 位值 0xAABBCCDD 为例, 字母表示各字节在报文中的顺序; 64 位值依此类推 (如 CDAB 为 GHEFCDAB)
+type WordOrder uint8
+
+const (
+	OrderABCD WordOrder = iota // 大端 (高字在前, 字内高字节在前)
+	OrderCDAB                  // 字交换 (低字在前, 字内高字节在前)
+	OrderBADC                  // 字节交换 (高字在前, 字内低字节在前)
+	OrderDCBA                  // 小端 (低字在前, 字内低字节在前)
+)
+
+func (o WordOrder) String() string {
+	switch o {
+	case OrderABCD:
+		return "ABCD"
+	case OrderCDAB:
+		return "CDAB"
+	case OrderBADC:
+		return "BADC"
+	case OrderDCBA:
+		return "DCBA"
+	}
+	return "unknown word order"
+}
+
+// 字符串填充字符 (String Padding)
+const (
+	PadNul   = 0x00 // 以 NUL 填充
+	PadSpace = 0x20 // 以空格填充
+)
+
+// 数据表 (Data Table)
+type Table uint8
+
+const (
+	TableCoil     Table = iota + 1 // 线圈
+	TableDiscrete                  // 离散量输入
+	TableInput                     // 输入寄存器
+	TableHold                      // 保持寄存器
+)
+
+func (t Table) String() string {
+	switch t {
+	case TableCoil:
+		return "coil"
+	case TableDiscrete:
+		return "discrete"
+	case TableInput:
+		return "input"
+	case TableHold:
+		return "hold"
+	}
+	return "unknown table"
+}
+
+// 是否为位数据表 (线圈/离散量输入)
+func (t Table) isBit() bool {
+	return t == TableCoil || t == TableDiscrete
+}
+
+// 是否为只读数据表 (离散量输入/输入寄存器)
+func (t Table) isReadOnly() bool {
+	return t == TableDiscrete || t == TableInput
+}
+
+// 单个读请求的最大数量 (位或寄存器)
+func (t Table) maxRead() int {
+	if t.isBit() {
+		return maxReadBits
+	}
+	return maxReadHoldBytes / 2
+}
+
+// 单个写请求的最大数量 (位或寄存器)
+func (t Table) maxWrite() int {
+	if t.isBit() {
+		return maxWriteCoils
+	}
+	return maxWriteHoldsBytes / 2
+}
+
 // Modbus 错误码 (Modbus Exception Code)
 const (
 	ExcepNormal         = 0x00 // 正常 (Normal)
@@ -105,6 +293,36 @@ func ExcepToString(b uint8) string {
 	}
 }
 
+// Modbus 异常响应错误 (Modbus Exception Error)
+type ExceptionError struct {
+	FuncCode uint8 // 功能码 (不含 0x80)
+	Code     uint8 // 异常码
+}
+
+func (e *ExceptionError) Error() string {
+	return "modbus exception: " + ExcepToString(e.Code) + " (" + FuncCodeToString(e.FuncCode) + ")"
+}
+
+// 异常码相同即匹配, 目标的功能码为 0 时匹配任意功能码
+func (e *ExceptionError) Is(target error) bool {
+	t, ok := target.(*ExceptionError)
+	return ok && t.Code == e.Code && (t.FuncCode == 0 || t.FuncCode == e.FuncCode)
+}
+
+// 定义异常响应错误, 用于 errors.Is 判断
+var (
+	ErrIllFuncCode    = &ExceptionError{Code: ExcepIllFuncCode}
+	ErrIllDataAddr    = &ExceptionError{Code: ExcepIllDataAddr}
+	ErrIllDataValue   = &ExceptionError{Code: ExcepIllDataValue}
+	ErrSlaveFail      = &ExceptionError{Code: ExcepSlaveFail}
+	ErrAck            = &ExceptionError{Code: ExcepAck}
+	ErrSlaveBusy      = &ExceptionError{Code: ExcepSlaveBusy}
+	ErrNAck           = &ExceptionError{Code: ExcepNAck}
+	ErrMemoryParity   = &ExceptionError{Code: ExcepMemoryParity}
+	ErrGwPathUnav     = &ExceptionError{Code: ExcepGwPathUnav}
+	ErrGwDevNoRespond = &ExceptionError{Code: ExcepGwDevNoRespond}
+)
+
 // 处理结果 (Process Result)
 const (
 	ResultNormal       = iota // 正常
@@ -124,6 +342,17 @@ const (
 	ResultTcpProtocol         // 协议标识错误 (Modbus TCP)
 	ResultBufTooShort         // 缓冲区过短
 	ResultUnknownError        // 未知错误
+	ResultAsciiChar           // 字符错误 (Modbus Ascii)
+	ResultNoResponse          // 无需响应
+	ResultTlsCert             // 客户端证书错误 (Modbus/TCP Security)
+	ResultTlsRole             // 角色扩展错误 (Modbus/TCP Security)
+	ResultBroadcast           // 广播请求不支持该功能码
+	ResultRegWidth            // 寄存器宽度错误 (Enron Modbus)
+	ResultStruct              // 结构体定义错误
+	ResultFieldOverlap        // 结构体字段重叠
+	ResultFieldRange          // 结构体字段超出范围
+	ResultRegMap              // 寄存器映射表错误
+	ResultPointAccess         // 点位访问权限错误
 )
 
 // 处理结果错误 (Process Result Error)
@@ -159,4 +388,15 @@ var (
 	ErrResultTcpProtocol  = &ErrResult{Code: ResultTcpProtocol, Zh: "协议标识错误 (Modbus TCP)", Err: errors.New("protocol identifier error (Modbus TCP)")}
 	ErrResultBufTooShort  = &ErrResult{Code: ResultBufTooShort, Zh: "缓冲区过短", Err: errors.New("buffer too short")}
 	ErrResultUnknownError = &ErrResult{Code: ResultUnknownError, Zh: "未知错误", Err: errors.New("unknown error")}
+	ErrResultAsciiChar    = &ErrResult{Code: ResultAsciiChar, Zh: "字符错误 (Modbus Ascii)", Err: errors.New("character error (Modbus Ascii)")}
+	ErrResultNoResponse   = &ErrResult{Code: ResultNoResponse, Zh: "无需响应", Err: errors.New("no response required")}
+	ErrResultTlsCert      = &ErrResult{Code: ResultTlsCert, Zh: "客户端证书错误 (Modbus/TCP Security)", Err: errors.New("client certificate error (Modbus/TCP Security)")}
+	ErrResultTlsRole      = &ErrResult{Code: ResultTlsRole, Zh: "角色扩展错误 (Modbus/TCP Security)", Err: errors.New("role extension error (Modbus/TCP Security)")}
+	ErrResultBroadcast    = &ErrResult{Code: ResultBroadcast, Zh: "广播请求不支持该功能码", Err: errors.New("function code not allowed in broadcast")}
+	ErrResultRegWidth     = &ErrResult{Code: ResultRegWidth, Zh: "寄存器宽度错误 (Enron Modbus)", Err: errors.New("register width error (Enron Modbus)")}
+	ErrResultStruct       = &ErrResult{Code: ResultStruct, Zh: "结构体定义错误", Err: errors.New("invalid struct definition")}
+	ErrResultFieldOverlap = &ErrResult{Code: ResultFieldOverlap, Zh: "结构体字段重叠", Err: errors.New("struct fields overlap")}
+	ErrResultFieldRange   = &ErrResult{Code: ResultFieldRange, Zh: "结构体字段超出范围", Err: errors.New("struct field out of range")}
+	ErrResultRegMap       = &ErrResult{Code: ResultRegMap, Zh: "寄存器映射表错误", Err: errors.New("invalid register map")}
+	ErrResultPointAccess  = &ErrResult{Code: ResultPointAccess, Zh: "点位访问权限错误", Err: errors.New("point access denied")}
 )
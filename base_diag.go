@@ -0,0 +1,185 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+const maxCommEvents = 64 // 通信事件日志最大条数
+
+// 诊断计数器 (串行链路)
+type DiagCounters struct {
+	BusMessage     uint16 // 总线报文数 (校验正确的报文, 含发往其他设备的报文)
+	BusCommError   uint16 // 总线通信错误数 (CRC/LRC 错误)
+	BusExcepError  uint16 // 异常响应数
+	ServerMessage  uint16 // 从机报文数 (发往本机的报文)
+	ServerNoResp   uint16 // 从机无响应数
+	ServerNak      uint16 // 从机否定应答数
+	ServerBusy     uint16 // 从机忙数
+	BusCharOverrun uint16 // 总线字符溢出数
+	CommEvent      uint16 // 通信事件计数 (成功处理的报文)
+}
+
+// Modbus-诊断计数器 (串行链路从机)
+// 由 rtuParse/asciiParse 统计接收的报文, 由 rtuPack/asciiPack 统计发送的响应
+type groDiag struct {
+	counters    DiagCounters
+	register    uint16               // 诊断寄存器
+	excepStatus uint8                // 异常状态 (读异常状态)
+	serverId    []uint8              // 从机标识数据 (报告从机标识)
+	listenOnly  bool                 // 是否处于只听模式
+	events      [maxCommEvents]uint8 // 通信事件日志 (环形缓冲区)
+	nevent      int                  // 事件数量
+	next        int                  // 下一个事件的写入位置
+}
+
+func (d *groDiag) Reset() {
+	*d = groDiag{}
+}
+
+// 清除计数器与诊断寄存器
+func (d *groDiag) ClearCounters() {
+	d.counters = DiagCounters{}
+	d.register = 0
+}
+
+// 清除通信事件日志
+func (d *groDiag) ClearEvents() {
+	d.nevent = 0
+	d.next = 0
+}
+
+// 设置 诊断寄存器
+func (d *groDiag) SetRegister(register uint16) {
+	d.register = register
+}
+
+// 设置 异常状态 (8 个异常状态位, 含义由设备定义)
+func (d *groDiag) SetExcepStatus(status uint8) {
+	d.excepStatus = status
+}
+
+// 设置 从机标识数据
+// 数据内容由设备定义, 通常为 从机标识 + 运行状态 (0x00-OFF, 0xFF-ON) + 附加数据
+func (d *groDiag) SetServerId(data []uint8) {
+	d.serverId = data
+}
+
+// 记录 字符溢出 (由传输层调用)
+func (d *groDiag) AddCharOverrun() {
+	d.counters.BusCharOverrun++
+	d.logEvent(EventRecv | EventRecvCharOverrun)
+}
+
+func (d *groDiag) GetCounters() DiagCounters {
+	return d.counters
+}
+
+func (d *groDiag) GetRegister() uint16 {
+	return d.register
+}
+
+func (d *groDiag) GetExcepStatus() uint8 {
+	return d.excepStatus
+}
+
+func (d *groDiag) GetServerId() []uint8 {
+	return d.serverId
+}
+
+func (d *groDiag) IsListenOnly() bool {
+	return d.listenOnly
+}
+
+// 获取 通信事件日志 (最新的事件在前)
+func (d *groDiag) GetEvents() []uint8 {
+	return d.appendEvents(nil)
+}
+
+// 追加通信事件日志 (最新的事件在前)
+func (d *groDiag) appendEvents(dst []uint8) []uint8 {
+	for i := 1; i <= d.nevent; i++ {
+		dst = append(dst, d.events[(d.next-i+maxCommEvents)%maxCommEvents])
+	}
+	return dst
+}
+
+// 记录通信事件
+func (d *groDiag) logEvent(event uint8) {
+	d.events[d.next] = event
+	d.next = (d.next + 1) % maxCommEvents
+	if d.nevent < maxCommEvents {
+		d.nevent++
+	}
+}
+
+// 统计 校验正确但发往其他设备的报文
+func (d *groDiag) countBusMessage() {
+	d.counters.BusMessage++
+}
+
+// 统计 校验错误的报文
+func (d *groDiag) countCommError() {
+	d.counters.BusCommError++
+	d.logEvent(EventRecv | EventRecvCommError)
+}
+
+// 统计 发往本机的报文 (含广播报文)
+func (d *groDiag) countServerMessage(broadcast bool) {
+	d.counters.BusMessage++
+	d.counters.ServerMessage++
+	event := uint8(EventRecv)
+	if d.listenOnly {
+		event |= EventRecvListenOnly
+	}
+	if broadcast {
+		event |= EventRecvBroadcast
+	}
+	d.logEvent(event)
+}
+
+// 统计 发送的响应
+func (d *groDiag) countResponse(funccode, excep uint8) {
+	event := uint8(EventSend)
+	switch excep {
+	case ExcepNormal:
+		// 获取通信事件计数/日志不计入通信事件计数
+		if funccode != FuncCodeGetCommEventCounter && funccode != FuncCodeGetCommEventLog {
+			d.counters.CommEvent++
+		}
+	case ExcepIllFuncCode, ExcepIllDataAddr, ExcepIllDataValue:
+		event |= EventSendReadExcep
+	case ExcepSlaveFail:
+		event |= EventSendAbortExcep
+	case ExcepAck, ExcepSlaveBusy:
+		event |= EventSendBusyExcep
+	case ExcepNAck:
+		event |= EventSendNakExcep
+	}
+
+	if excep != ExcepNormal {
+		d.counters.BusExcepError++
+	}
+	if excep == ExcepSlaveBusy {
+		d.counters.ServerBusy++
+	} else if excep == ExcepNAck {
+		d.counters.ServerNak++
+	}
+	d.logEvent(event)
+}
+
+// 重启通信: 退出只听模式, 清除计数器
+func (d *groDiag) restart(clearLog bool) {
+	d.listenOnly = false
+	d.ClearCounters()
+	if clearLog {
+		d.ClearEvents()
+	}
+	d.logEvent(EventRestart)
+}
+
+// 进入只听模式
+func (d *groDiag) forceListenOnly() {
+	d.listenOnly = true
+	d.logEvent(EventListenOnly)
+}
@@ -5,10 +5,16 @@
 
 package gromb
 
+import "time"
+
+const DefaultTurnaround = 100 * time.Millisecond // 默认广播转换延时
+
 type groHead struct {
-	protocol uint8  // 协议类型
-	devid    uint8  // 设备标识
-	sernum   uint16 // 序列号
+	protocol   uint8         // 协议类型
+	devid      uint8         // 设备标识
+	sernum     uint16        // 序列号
+	asciiEnd   uint8         // 结束符第二字节 (Modbus Ascii, 0 表示 LF)
+	turnaround time.Duration // 广播转换延时 (主站发送广播请求后的等待时长)
 }
 
 func (h *groHead) InitRtu(devid uint8) {
@@ -29,8 +35,22 @@ func (h *groHead) InitTcp(devid uint8, sernum uint16) {
 	h.sernum = sernum
 }
 
+func (h *groHead) InitRtuOverTcp(devid uint8) {
+	h.protocol = ProtocolRtuOverTcp
+	h.devid = devid
+	h.sernum = 0
+}
+
+func (h *groHead) InitAsciiOverTcp(devid uint8) {
+	h.protocol = ProtocolAsciiOverTcp
+	h.devid = devid
+	h.sernum = 0
+}
+
 func (h *groHead) Reset() {
 	h.InitRtu(0)
+	h.asciiEnd = EndLow
+	h.turnaround = DefaultTurnaround
 }
 
 func (h *groHead) SetProtocol(protocol uint8) {
@@ -45,6 +65,16 @@ func (h *groHead) SetSerNum(sernum uint16) {
 	h.sernum = sernum
 }
 
+// 设置 Ascii 结束符第二字节 (诊断子功能 0x03 可修改)
+func (h *groHead) SetAsciiEnd(end uint8) {
+	h.asciiEnd = end
+}
+
+// 设置 广播转换延时, 从机处理广播请求所需的时间
+func (h *groHead) SetTurnaround(turnaround time.Duration) {
+	h.turnaround = turnaround
+}
+
 func (h *groHead) IncSerNum() {
 	h.sernum++
 }
@@ -61,6 +91,28 @@ func (h *groHead) GetSerNum() uint16 {
 	return h.sernum
 }
 
+// 获取 Ascii 结束符第二字节, 未设置时 (如零值经 InitAscii 初始化) 为 LF
+func (h *groHead) GetAsciiEnd() uint8 {
+	if h.asciiEnd == 0 {
+		return EndLow
+	}
+	return h.asciiEnd
+}
+
+func (h *groHead) GetTurnaround() time.Duration {
+	return h.turnaround
+}
+
+// 是否为广播 (串行链路协议且设备标识为 0, Modbus TCP 不支持广播)
+func (h *groHead) IsBroadcast() bool {
+	return h.devid == DevIdBroadcast && h.protocol != ProtocolTCP
+}
+
+// 是否为透传协议 (RTU/Ascii over TCP)
+func (h *groHead) isOverTcp() bool {
+	return h.protocol == ProtocolRtuOverTcp || h.protocol == ProtocolAsciiOverTcp
+}
+
 func (h *groHead) GetProtocolString() string {
 	return ProtocolToString(h.protocol)
 }
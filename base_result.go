@@ -10,12 +10,14 @@ type groResult struct {
 	excepCode uint8  // 异常码
 	errResult error  // 处理结果
 	retlen    uint16 // 封装/解析实际长度
+	noresp    bool   // 请求无需响应 (只听模式)
 }
 
 func (r *groResult) Reset() {
 	r.errResult = nil
 	r.excepCode = ExcepNormal
 	r.retlen = 0
+	r.noresp = false
 }
 
 func (r *groResult) SetExcepCode(code uint8) {
@@ -30,6 +32,10 @@ func (r *groResult) SetRetLen(retlen uint16) {
 	r.retlen = retlen
 }
 
+func (r *groResult) SetNoResponse(noresp bool) {
+	r.noresp = noresp
+}
+
 func (r *groResult) GetExcepCode() uint8 {
 	return r.excepCode
 }
@@ -42,6 +48,10 @@ func (r *groResult) GetRetLen() uint16 {
 	return r.retlen
 }
 
+func (r *groResult) GetNoResponse() bool {
+	return r.noresp
+}
+
 func (r *groResult) GetExcepCodeString() string {
 	return ExcepToString(r.excepCode)
 }
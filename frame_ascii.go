@@ -0,0 +1,105 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"io"
+)
+
+// Modbus Ascii 流式分帧器
+// 丢弃起始符 ':' 之前的噪声, 帧内出现 ':' 时重新开始, 以 CR + 结束符 (默认为 LF) 结束
+type AsciiFrameReader struct {
+	r     io.Reader
+	end   uint8                   // 结束符第二字节
+	lower bool                    // 是否接受小写十六进制字符
+	frame [MaxAsciiFrameLen]uint8 // 当前帧
+	n     int                     // 当前帧长度
+	buf   [MaxAsciiFrameLen]uint8 // 接收缓冲区
+	start int                     // 未处理数据的起始位置
+	stop  int                     // 未处理数据的结束位置
+}
+
+func NewAsciiFrameReader(r io.Reader) *AsciiFrameReader {
+	return &AsciiFrameReader{r: r, end: EndLow}
+}
+
+// 设置 结束符第二字节 (对应诊断子功能 0x03, 需与 Head.SetAsciiEnd 保持一致)
+func (f *AsciiFrameReader) SetDelimiter(end uint8) {
+	f.end = end
+}
+
+// 设置 是否接受小写十六进制字符 (返回的帧中转换为大写)
+func (f *AsciiFrameReader) SetLowerHex(lower bool) {
+	f.lower = lower
+}
+
+// 读取一个完整的 ADU
+// 返回的切片在下一次调用前有效
+// 返回值: 流在帧边界结束时返回 io.EOF, 在帧中间结束时返回 ErrResultTooShort
+func (f *AsciiFrameReader) ReadFrame() ([]uint8, error) {
+	for {
+		if f.start == f.stop {
+			c, err := f.r.Read(f.buf[:])
+			f.start, f.stop = 0, c
+			if c == 0 {
+				if err == io.EOF {
+					if f.n == 0 {
+						return nil, io.EOF
+					}
+					f.n = 0
+					return nil, ErrResultTooShort
+				}
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		c := f.buf[f.start]
+		f.start++
+
+		// 起始符: 开始新的帧 (丢弃未完成的帧)
+		if c == StartChar {
+			f.frame[0] = c
+			f.n = 1
+			continue
+		}
+		// 等待起始符, 丢弃噪声
+		if f.n == 0 {
+			continue
+		}
+
+		// 结束符
+		if f.frame[f.n-1] == EndHigh {
+			if c != f.end {
+				f.n = 0
+				return nil, ErrResultAsciiEnd
+			}
+			f.frame[f.n] = c
+			n := f.n + 1
+			f.n = 0
+			return f.frame[:n], nil
+		}
+
+		switch {
+		case c == EndHigh:
+		case c >= '0' && c <= '9', c >= 'A' && c <= 'F':
+		case f.lower && c >= 'a' && c <= 'f':
+			c -= 'a' - 'A'
+		default:
+			f.n = 0
+			return nil, ErrResultAsciiChar
+		}
+
+		if f.n >= MaxAsciiFrameLen-1 {
+			f.n = 0
+			return nil, ErrResultLength
+		}
+		f.frame[f.n] = c
+		f.n++
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestAsciiFrameReader(t *testing.T) {
+	var stream []uint8
+	for i := range testItems {
+		stream = append(stream, "noise"...)
+		stream = append(stream, testItems[i].packet(ProtocolAscii, true)...)
+	}
+
+	fr := NewAsciiFrameReader(iotest.OneByteReader(bytes.NewReader(stream)))
+	for i := range testItems {
+		frame, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("[%d] Failed to read frame: %v.\n", i, err)
+		}
+		if expect := testItems[i].packet(ProtocolAscii, true); !bytes.Equal(frame, expect) {
+			t.Fatalf("[%d] Frame mismatch: Expected = %q, Actual = %q.\n", i, expect, frame)
+		}
+	}
+	if _, err := fr.ReadFrame(); err != io.EOF {
+		t.Fatalf("Expected io.EOF at end of stream, got %v.\n", err)
+	}
+}
+
+func TestAsciiFrameReaderErrors(t *testing.T) {
+	frame := testItems[0].packet(ProtocolAscii, true)
+
+	// 帧内出现起始符时重新开始
+	{
+		input := append([]uint8(":0101"), frame...)
+		fr := NewAsciiFrameReader(bytes.NewReader(input))
+		if got, err := fr.ReadFrame(); err != nil || !bytes.Equal(got, frame) {
+			t.Fatalf("Restart on ':' failed: got %q, %v.\n", got, err)
+		}
+	}
+
+	// 非十六进制字符
+	{
+		fr := NewAsciiFrameReader(bytes.NewReader([]uint8(":01G1\r\n")))
+		if _, err := fr.ReadFrame(); !errors.Is(err, ErrResultAsciiChar) {
+			t.Fatalf("Expected ErrResultAsciiChar, got %v.\n", err)
+		}
+	}
+
+	// 超过最大长度
+	{
+		input := append([]uint8(":"), bytes.Repeat([]uint8("0"), MaxAsciiFrameLen)...)
+		fr := NewAsciiFrameReader(bytes.NewReader(input))
+		if _, err := fr.ReadFrame(); !errors.Is(err, ErrResultLength) {
+			t.Fatalf("Expected ErrResultLength, got %v.\n", err)
+		}
+	}
+
+	// 帧中间结束
+	{
+		fr := NewAsciiFrameReader(bytes.NewReader(frame[:len(frame)-1]))
+		if _, err := fr.ReadFrame(); !errors.Is(err, ErrResultTooShort) {
+			t.Fatalf("Expected ErrResultTooShort, got %v.\n", err)
+		}
+	}
+
+	// 小写十六进制字符
+	{
+		lower := bytes.ToLower(frame)
+		fr := NewAsciiFrameReader(bytes.NewReader(lower))
+		if _, err := fr.ReadFrame(); !errors.Is(err, ErrResultAsciiChar) {
+			t.Fatalf("Expected ErrResultAsciiChar, got %v.\n", err)
+		}
+		fr = NewAsciiFrameReader(bytes.NewReader(lower))
+		fr.SetLowerHex(true)
+		if got, err := fr.ReadFrame(); err != nil || !bytes.Equal(got, frame) {
+			t.Fatalf("Lowercase hex failed: got %q, %v.\n", got, err)
+		}
+	}
+}
+
+func TestAsciiDelimiter(t *testing.T) {
+	item := &testItems[0]
+	m := New()
+	testSetup(m, ProtocolAscii, item)
+	m.Head.SetAsciiEnd('!')
+
+	req, err := m.AppendRequest(nil)
+	if err != nil {
+		t.Fatalf("Failed to pack request: %v.\n", err)
+	}
+	if req[len(req)-1] != '!' {
+		t.Fatalf("Delimiter mismatch: %q.\n", req)
+	}
+
+	fr := NewAsciiFrameReader(bytes.NewReader(req))
+	fr.SetDelimiter('!')
+	frame, err := fr.ReadFrame()
+	if err != nil {
+		t.Fatalf("Failed to read frame: %v.\n", err)
+	}
+	if err := m.ParseRequest(frame); err != nil {
+		t.Fatalf("Failed to parse request: %v.\n", err)
+	}
+
+	m.Head.SetAsciiEnd(EndLow)
+	if err := m.ParseRequest(frame); !errors.Is(err, ErrResultAsciiEnd) {
+		t.Fatalf("Expected ErrResultAsciiEnd, got %v.\n", err)
+	}
+
+	// 零值的报文头经 InitAscii 初始化后, 结束符为 CR LF
+	m.Head = groHead{}
+	m.Head.InitAscii(item.devid)
+	req, err = m.AppendRequest(nil)
+	if err != nil || !bytes.HasSuffix(req, []uint8{EndHigh, EndLow}) {
+		t.Fatalf("Expected CR LF, got %q, %v.\n", req, err)
+	}
+	if err := m.ParseRequest(req); err != nil {
+		t.Fatalf("Failed to parse CR LF request: %v.\n", err)
+	}
+}
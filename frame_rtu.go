@@ -0,0 +1,341 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// RTU 字符间隔 t1.5 与帧间隔 t3.5
+// 每个字符按 11 位计算, 波特率高于 19200 时使用规范推荐的固定值 750us/1750us
+func RTUTimings(baud int) (t15, t35 time.Duration) {
+	if baud <= 0 {
+		return 0, 0
+	}
+	if baud > 19200 {
+		return 750 * time.Microsecond, 1750 * time.Microsecond
+	}
+	t15 = time.Duration(11*3) * time.Second / time.Duration(baud*2)
+	t35 = time.Duration(11*7) * time.Second / time.Duration(baud*2)
+	return t15, t35
+}
+
+// 预测 RTU 帧的完整长度 (含地址与 CRC)
+// 返回值: >0-完整帧长度, 0-数据不足以预测, -1-无法预测 (未知功能码, 或诊断子功能 0x00 的变长回送数据, 需依据静默间隔分帧)
+func RTUFrameLen(adu []uint8, isReq bool) int {
+	if len(adu) < 2 {
+		return 0
+	}
+
+	funccode := adu[1]
+	if isReq {
+		switch funccode {
+		case FuncCodeReadCoil,
+			FuncCodeReadDiscrete,
+			FuncCodeReadHold,
+			FuncCodeReadInput,
+			FuncCodeWriteCoil,
+			FuncCodeWriteHold:
+			return 8
+		case FuncCodeDiag:
+			return rtuDiagLen(adu)
+		case FuncCodeReadExcepStatus,
+			FuncCodeGetCommEventCounter,
+			FuncCodeGetCommEventLog,
+			FuncCodeReportServerId:
+			return 4
+		case FuncCodeWriteCoils,
+			FuncCodeWriteHolds:
+			// 地址(1) + 功能码(1) + 起始地址(2) + 数量(2) + 字节数(1) + 数据(n) + CRC(2)
+			if len(adu) < 7 {
+				return 0
+			}
+			return 9 + int(adu[6])
+		case FuncCodeMaskWriteHold:
+			return 10
+		case FuncCodeReadWriteHolds:
+			// 地址(1) + 功能码(1) + 读/写地址与数量(8) + 字节数(1) + 数据(n) + CRC(2)
+			if len(adu) < 11 {
+				return 0
+			}
+			return 13 + int(adu[10])
+		case FuncCodeReadFile,
+			FuncCodeWriteFile:
+			// 地址(1) + 功能码(1) + 字节数(1) + 子请求(n) + CRC(2)
+			if len(adu) < 3 {
+				return 0
+			}
+			return 5 + int(adu[2])
+		case FuncCodeReadFifo:
+			return 6
+		case FuncCodeMei:
+			return 7
+		}
+		return -1
+	}
+
+	if funccode&0x80 != 0 {
+		return 5
+	}
+	switch funccode {
+	case FuncCodeReadCoil,
+		FuncCodeReadDiscrete,
+		FuncCodeReadHold,
+		FuncCodeReadInput,
+		FuncCodeReadWriteHolds,
+		FuncCodeReadFile,
+		FuncCodeWriteFile,
+		FuncCodeGetCommEventLog,
+		FuncCodeReportServerId:
+		// 地址(1) + 功能码(1) + 字节数(1) + 数据(n) + CRC(2)
+		if len(adu) < 3 {
+			return 0
+		}
+		return 5 + int(adu[2])
+	case FuncCodeWriteCoil,
+		FuncCodeWriteHold,
+		FuncCodeWriteCoils,
+		FuncCodeWriteHolds,
+		FuncCodeGetCommEventCounter:
+		return 8
+	case FuncCodeDiag:
+		return rtuDiagLen(adu)
+	case FuncCodeReadExcepStatus:
+		return 5
+	case FuncCodeMaskWriteHold:
+		return 10
+	case FuncCodeReadFifo:
+		// 地址(1) + 功能码(1) + 字节数(2) + 数据(n) + CRC(2)
+		if len(adu) < 4 {
+			return 0
+		}
+		return 6 + int(binary.BigEndian.Uint16(adu[2:4]))
+	case FuncCodeMei:
+		return rtuDevIdentLen(adu)
+	}
+	return -1
+}
+
+// 预测诊断帧的完整长度 (请求与响应格式相同)
+// 子功能 0x00 的回送数据为 N x 2 字节, 无法依据报文内容预测
+func rtuDiagLen(adu []uint8) int {
+	if len(adu) < 4 {
+		return 0
+	}
+	if binary.BigEndian.Uint16(adu[2:4]) == DiagReturnQueryData {
+		return -1
+	}
+	return 8
+}
+
+// 预测读设备标识响应帧的完整长度
+// 地址(1) + 功能码(1) + 头部(6) + 对象列表(对象ID(1) + 长度(1) + 值(n)) + CRC(2)
+func rtuDevIdentLen(adu []uint8) int {
+	if len(adu) < 8 {
+		return 0
+	}
+	pos := 8
+	for i := 0; i < int(adu[7]); i++ {
+		if len(adu) < pos+2 {
+			return 0
+		}
+		pos += 2 + int(adu[pos+1])
+	}
+	return pos + 2
+}
+
+// 支持读超时的数据源 (如 net.Conn 及大多数串口库)
+type deadlineReader interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// Modbus RTU 流式分帧器
+// 支持两种分帧方式:
+//   - 长度预测: 依据功能码与字节数字段判断帧是否完整, 不依赖计时 (默认启用)
+//   - 静默间隔: 依据波特率计算 t3.5 帧间隔, 数据源需支持 SetReadDeadline
+//
+// 两者同时启用时, 优先使用长度预测, 未知功能码时使用静默间隔, 并由静默间隔丢弃不完整的帧
+type RTUFrameReader struct {
+	r       io.Reader
+	isReq   bool                 // 读取请求报文 (从机) 或响应报文 (主机)
+	predict bool                 // 是否启用长度预测
+	strict  bool                 // 是否检查 t1.5 字符间隔
+	t15     time.Duration        // 字符间隔
+	t35     time.Duration        // 帧间隔
+	buf     [MaxRTULen * 2]uint8 // 接收缓冲区
+	start   int                  // 未处理数据的起始位置
+	end     int                  // 未处理数据的结束位置
+}
+
+func NewRTUFrameReader(r io.Reader, isReq bool) *RTUFrameReader {
+	return &RTUFrameReader{r: r, isReq: isReq, predict: true}
+}
+
+// 设置 波特率, 启用静默间隔分帧 (0 表示禁用)
+func (f *RTUFrameReader) SetBaudRate(baud int) {
+	f.t15, f.t35 = RTUTimings(baud)
+}
+
+// 设置 字符间隔与帧间隔, 启用静默间隔分帧 (用于 USB 转换器等需要放宽计时的场景)
+func (f *RTUFrameReader) SetTimings(t15, t35 time.Duration) {
+	f.t15, f.t35 = t15, t35
+}
+
+// 设置 是否启用长度预测
+func (f *RTUFrameReader) SetPredict(predict bool) {
+	f.predict = predict
+}
+
+// 设置 是否检查 t1.5 字符间隔, 字符间隔超过 t1.5 的帧将被丢弃
+func (f *RTUFrameReader) SetStrict(strict bool) {
+	f.strict = strict
+}
+
+// 读取一个完整的 ADU
+// 返回的切片在下一次调用前有效
+func (f *RTUFrameReader) ReadFrame() ([]uint8, error) {
+	dr, timing := f.r.(deadlineReader)
+	timing = timing && f.t35 > 0
+
+	for {
+		data := f.buf[f.start:f.end]
+
+		// 长度预测
+		if f.predict && len(data) > 0 {
+			n := RTUFrameLen(data, f.isReq)
+			if n > MaxRTULen {
+				f.discard()
+				return nil, ErrResultLength
+			}
+			if n > 0 && len(data) >= n {
+				f.start += n
+				return data[:n], nil
+			}
+			if n < 0 && !timing {
+				f.discard()
+				return nil, ErrResultFuncCode
+			}
+		}
+
+		if len(data) >= MaxRTULen {
+			f.discard()
+			return nil, ErrResultLength
+		}
+		f.compact()
+
+		// 设置读超时: 等待首字节时不超时, 之后等待 t1.5 (严格模式) 或 t3.5
+		wait := time.Duration(0)
+		if timing {
+			var deadline time.Time
+			if len(data) > 0 {
+				wait = f.t35
+				if f.strict {
+					wait = f.t15
+				}
+				deadline = time.Now().Add(wait)
+			}
+			if err := dr.SetReadDeadline(deadline); err != nil {
+				return nil, err
+			}
+		}
+
+		c, err := f.r.Read(f.buf[f.end:])
+		f.end += c
+		if c > 0 || err == nil {
+			continue
+		}
+
+		switch {
+		case isTimeout(err):
+			if f.strict && !f.waitSilence(dr) {
+				// t1.5 之后 t3.5 之前收到字符, 帧不完整
+				f.dropUntilSilence(dr)
+				return nil, ErrResultLength
+			}
+			return f.endFrame()
+		case err == io.EOF:
+			if f.start == f.end {
+				return nil, io.EOF
+			}
+			if timing && !f.predict {
+				return f.endFrame()
+			}
+			f.discard()
+			return nil, ErrResultTooShort
+		default:
+			return nil, err
+		}
+	}
+}
+
+// 静默间隔到达, 结束当前帧
+func (f *RTUFrameReader) endFrame() ([]uint8, error) {
+	data := f.buf[f.start:f.end]
+	f.discard()
+
+	if len(data) < MinRTULen {
+		return nil, ErrResultTooShort
+	}
+	if f.predict {
+		// 已知功能码但数据不足, 说明帧不完整
+		if n := RTUFrameLen(data, f.isReq); n > 0 && len(data) < n {
+			return nil, ErrResultTooShort
+		}
+	}
+	return data, nil
+}
+
+// 等待剩余的 t3.5 - t1.5, 返回值: true-期间未收到字符
+func (f *RTUFrameReader) waitSilence(dr deadlineReader) bool {
+	if err := dr.SetReadDeadline(time.Now().Add(f.t35 - f.t15)); err != nil {
+		return false
+	}
+	f.compact()
+	c, _ := f.r.Read(f.buf[f.end:])
+	f.end += c
+	return c == 0
+}
+
+// 丢弃数据直至出现 t3.5 静默间隔
+func (f *RTUFrameReader) dropUntilSilence(dr deadlineReader) {
+	for {
+		f.discard()
+		if err := dr.SetReadDeadline(time.Now().Add(f.t35)); err != nil {
+			return
+		}
+		c, err := f.r.Read(f.buf[:])
+		if c == 0 || err != nil {
+			f.discard()
+			return
+		}
+	}
+}
+
+// 丢弃缓冲区中的所有数据
+func (f *RTUFrameReader) discard() {
+	f.start, f.end = 0, 0
+}
+
+// 将未处理的数据移动到缓冲区头部
+func (f *RTUFrameReader) compact() {
+	if f.start > 0 {
+		f.end = copy(f.buf[:], f.buf[f.start:f.end])
+		f.start = 0
+	}
+}
+
+// 判断是否为读超时错误
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var t interface{ Timeout() bool }
+	return errors.As(err, &t) && t.Timeout()
+}
@@ -0,0 +1,194 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// 模拟串口: 按顺序返回数据块, gap 表示线路静默的时长
+type rtuLineEvent struct {
+	data []uint8
+	gap  time.Duration
+}
+
+type rtuLine struct {
+	events   []rtuLineEvent
+	deadline time.Time
+}
+
+func (l *rtuLine) SetReadDeadline(t time.Time) error {
+	l.deadline = t
+	return nil
+}
+
+func (l *rtuLine) Read(p []uint8) (int, error) {
+	for len(l.events) > 0 {
+		ev := &l.events[0]
+		if ev.gap > 0 {
+			if l.deadline.IsZero() {
+				l.events = l.events[1:]
+				continue
+			}
+			remain := time.Until(l.deadline)
+			if ev.gap > remain {
+				ev.gap -= remain
+				return 0, os.ErrDeadlineExceeded
+			}
+			l.events = l.events[1:]
+			continue
+		}
+		n := copy(p, ev.data)
+		ev.data = ev.data[n:]
+		if len(ev.data) == 0 {
+			l.events = l.events[1:]
+		}
+		return n, nil
+	}
+	if !l.deadline.IsZero() {
+		return 0, os.ErrDeadlineExceeded
+	}
+	return 0, io.EOF
+}
+
+func TestRTUTimings(t *testing.T) {
+	t15, t35 := RTUTimings(9600)
+	if t15 != 1718750*time.Nanosecond || t35 != 4010416*time.Nanosecond {
+		t.Fatalf("RTUTimings(9600) = %v, %v.\n", t15, t35)
+	}
+	t15, t35 = RTUTimings(115200)
+	if t15 != 750*time.Microsecond || t35 != 1750*time.Microsecond {
+		t.Fatalf("RTUTimings(115200) = %v, %v.\n", t15, t35)
+	}
+}
+
+func TestRTUFrameReaderPredict(t *testing.T) {
+	for _, isReq := range []bool{true, false} {
+		var stream [][]uint8
+		var all []uint8
+		for i := range testItems {
+			frame := testItems[i].packet(ProtocolRTU, isReq)
+			frame = frame[:RTUFrameLen(frame, isReq)]
+			stream = append(stream, frame)
+			all = append(all, frame...)
+		}
+
+		fr := NewRTUFrameReader(iotest.OneByteReader(bytes.NewReader(all)), isReq)
+		for i, expect := range stream {
+			frame, err := fr.ReadFrame()
+			if err != nil {
+				t.Fatalf("[%d] Failed to read frame: %v.\n", i, err)
+			}
+			if !bytes.Equal(frame, expect) {
+				t.Fatalf("[%d] Frame mismatch: Expected = %s, Actual = %s.\n", i, strFromHex(expect), strFromHex(frame))
+			}
+		}
+		if _, err := fr.ReadFrame(); err != io.EOF {
+			t.Fatalf("Expected io.EOF at end of stream, got %v.\n", err)
+		}
+	}
+
+	// 异常响应
+	{
+		excep := strToHex("01 83 02 C0 F1")
+		fr := NewRTUFrameReader(bytes.NewReader(excep), false)
+		if frame, err := fr.ReadFrame(); err != nil || !bytes.Equal(frame, excep) {
+			t.Fatalf("Exception frame: got %s, %v.\n", strFromHex(frame), err)
+		}
+	}
+
+	// 未知功能码且未启用静默间隔
+	{
+		fr := NewRTUFrameReader(bytes.NewReader(strToHex("01 64 00 00")), true)
+		if _, err := fr.ReadFrame(); !errors.Is(err, ErrResultFuncCode) {
+			t.Fatalf("Expected ErrResultFuncCode, got %v.\n", err)
+		}
+	}
+}
+
+func TestRTUFrameReaderTiming(t *testing.T) {
+	t15, t35 := RTUTimings(9600)
+	frame1 := strToHex("01 64 01 02 03 A9 2E")
+	frame2 := testItems[0].packet(ProtocolRTU, true)
+
+	// 未知功能码依据 t3.5 分帧
+	{
+		line := &rtuLine{events: []rtuLineEvent{
+			{data: frame1[:3]}, {gap: t15 / 2}, {data: frame1[3:]}, {gap: t35 * 2},
+			{data: frame2},
+		}}
+		fr := NewRTUFrameReader(line, true)
+		fr.SetBaudRate(9600)
+		if got, err := fr.ReadFrame(); err != nil || !bytes.Equal(got, frame1) {
+			t.Fatalf("Timing frame: got %s, %v.\n", strFromHex(got), err)
+		}
+		if got, err := fr.ReadFrame(); err != nil || !bytes.Equal(got, frame2) {
+			t.Fatalf("Predicted frame: got %s, %v.\n", strFromHex(got), err)
+		}
+	}
+
+	// 诊断子功能 0x00 的 N x 2 字节回送数据依据 t3.5 分帧
+	{
+		m := New()
+		m.Head.InitRtu(0x01)
+		m.Arg.SetFuncCode(FuncCodeDiag)
+		m.Arg.SetSubFunc(DiagReturnQueryData)
+		m.Arg.SetU16s([]uint16{0x0102, 0x0304, 0x0506}, binary.BigEndian)
+		echo, _ := m.AppendRequest(nil)
+		line := &rtuLine{events: []rtuLineEvent{
+			{data: echo}, {gap: t35 * 2},
+			{data: frame2},
+		}}
+		fr := NewRTUFrameReader(line, true)
+		fr.SetBaudRate(9600)
+		if got, err := fr.ReadFrame(); err != nil || !bytes.Equal(got, echo) {
+			t.Fatalf("Echo frame: got %s, %v.\n", strFromHex(got), err)
+		}
+		if got, err := fr.ReadFrame(); err != nil || !bytes.Equal(got, frame2) {
+			t.Fatalf("Frame after echo: got %s, %v.\n", strFromHex(got), err)
+		}
+	}
+
+	// 已知功能码但 t3.5 前数据不足, 丢弃不完整的帧
+	{
+		line := &rtuLine{events: []rtuLineEvent{
+			{data: frame2[:5]}, {gap: t35 * 2},
+			{data: frame2},
+		}}
+		fr := NewRTUFrameReader(line, true)
+		fr.SetBaudRate(9600)
+		if _, err := fr.ReadFrame(); !errors.Is(err, ErrResultTooShort) {
+			t.Fatalf("Expected ErrResultTooShort, got %v.\n", err)
+		}
+		if got, err := fr.ReadFrame(); err != nil || !bytes.Equal(got, frame2) {
+			t.Fatalf("Frame after silence: got %s, %v.\n", strFromHex(got), err)
+		}
+	}
+
+	// 严格模式: 字符间隔超过 t1.5 的帧被丢弃
+	{
+		line := &rtuLine{events: []rtuLineEvent{
+			{data: frame1[:3]}, {gap: (t15 + t35) / 2}, {data: frame1[3:]}, {gap: t35 * 2},
+			{data: frame1},
+		}}
+		fr := NewRTUFrameReader(line, true)
+		fr.SetBaudRate(9600)
+		fr.SetStrict(true)
+		if _, err := fr.ReadFrame(); !errors.Is(err, ErrResultLength) {
+			t.Fatalf("Expected ErrResultLength, got %v.\n", err)
+		}
+		if got, err := fr.ReadFrame(); err != nil || !bytes.Equal(got, frame1) {
+			t.Fatalf("Frame after silence: got %s, %v.\n", strFromHex(got), err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+//	<----------------- MBAP Header ----------------->
+//	+-----------+-----------+------------+-----------+---------------+
+//	| TID       | PID       | Length     | UID       | PDU           |
+//	| 2 Byte    | 2 Byte    | 2 Byte     | 1 Byte    | Length-1 Byte |
+//	+-----------+-----------+------------+-----------+---------------+
+
+const (
+	MaxTCPFrameLen = 260 // 最大 ADU 长度 (MBAP 7 字节 + PDU 253 字节)
+	mbapHeadLen    = 6   // 长度字段之前 (含长度字段) 的字节数
+)
+
+// Modbus TCP 流式分帧器
+// 依据 MBAP 长度字段, 从 io.Reader 中逐个读取完整的 ADU
+type TCPFrameReader struct {
+	r      io.Reader
+	buf    [MaxTCPFrameLen * 2]uint8 // 接收缓冲区
+	start  int                       // 未处理数据的起始位置
+	end    int                       // 未处理数据的结束位置
+	resync bool                      // 协议标识错误时是否重新同步
+}
+
+func NewTCPFrameReader(r io.Reader) *TCPFrameReader {
+	return &TCPFrameReader{r: r}
+}
+
+// 设置 协议标识/长度字段错误时是否丢弃字节并重新同步
+// 默认返回错误并丢弃已缓冲的数据, 此时帧边界已丢失, 通常应关闭连接
+func (f *TCPFrameReader) SetResync(resync bool) {
+	f.resync = resync
+}
+
+// 读取一个完整的 ADU
+// 返回的切片在下一次调用前有效
+// 返回值: 流在帧边界结束时返回 io.EOF, 在帧中间结束时返回 ErrResultTooShort
+func (f *TCPFrameReader) ReadFrame() ([]uint8, error) {
+	for {
+		if err := f.fill(mbapHeadLen + 1); err != nil {
+			return nil, err
+		}
+
+		head := f.buf[f.start:f.end]
+		number := int(binary.BigEndian.Uint16(head[4:6]))
+
+		// 检查协议标识与长度字段
+		var err error
+		if binary.BigEndian.Uint16(head[2:4]) != 0x0000 {
+			err = ErrResultTcpProtocol
+		} else if number < 2 {
+			err = ErrResultTooShort
+		} else if mbapHeadLen+number > MaxTCPFrameLen {
+			err = ErrResultLength
+		}
+		if err != nil {
+			if !f.resync {
+				f.start = f.end // 丢弃已缓冲的数据, 避免之后的调用重复返回同一错误
+				return nil, err
+			}
+			f.start++
+			continue
+		}
+
+		if err := f.fill(mbapHeadLen + number); err != nil {
+			return nil, err
+		}
+		frame := f.buf[f.start : f.start+mbapHeadLen+number]
+		f.start += len(frame)
+		return frame, nil
+	}
+}
+
+// 确保缓冲区中至少有 n 字节未处理的数据
+func (f *TCPFrameReader) fill(n int) error {
+	if f.start == f.end {
+		f.start, f.end = 0, 0
+	}
+	if f.start+n > len(f.buf) {
+		f.end = copy(f.buf[:], f.buf[f.start:f.end])
+		f.start = 0
+	}
+
+	for f.end-f.start < n {
+		c, err := f.r.Read(f.buf[f.end:])
+		f.end += c
+		if f.end-f.start >= n {
+			break
+		}
+		if err == io.EOF {
+			if f.end == f.start {
+				return io.EOF
+			}
+			return ErrResultTooShort
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTCPFrameReader(t *testing.T) {
+	var stream []uint8
+	for i := range testItems {
+		stream = append(stream, testItems[i].packet(ProtocolTCP, true)...)
+	}
+
+	readers := map[string]io.Reader{
+		"coalesced": bytes.NewReader(stream),
+		"split":     iotest.OneByteReader(bytes.NewReader(stream)),
+		"half":      iotest.HalfReader(bytes.NewReader(stream)),
+	}
+	for name, r := range readers {
+		t.Run(name, func(t *testing.T) {
+			m := New()
+			m.Head.SetProtocol(ProtocolTCP)
+			m.Access.SetCheckCoil(checkDefault)
+			m.Access.SetCheckDiscrete(checkDefault)
+			m.Access.SetCheckHold(checkDefault)
+			m.Access.SetCheckInput(checkDefault)
+
+			fr := NewTCPFrameReader(r)
+			for i := range testItems {
+				frame, err := fr.ReadFrame()
+				if err != nil {
+					t.Fatalf("[%d] Failed to read frame: %v.\n", i, err)
+				}
+				if expect := testItems[i].packet(ProtocolTCP, true); !bytes.Equal(frame, expect) {
+					t.Fatalf("[%d] Frame mismatch: Expected = %s, Actual = %s.\n", i, strFromHex(expect), strFromHex(frame))
+				}
+				if err := m.ParseRequest(frame); err != nil {
+					t.Fatalf("[%d] Failed to parse frame: %v.\n", i, err)
+				}
+			}
+			if _, err := fr.ReadFrame(); err != io.EOF {
+				t.Fatalf("Expected io.EOF at end of stream, got %v.\n", err)
+			}
+		})
+	}
+}
+
+func TestTCPFrameReaderErrors(t *testing.T) {
+	frame := testItems[0].packet(ProtocolTCP, true)
+
+	// 帧中间结束
+	{
+		fr := NewTCPFrameReader(bytes.NewReader(frame[:len(frame)-1]))
+		if _, err := fr.ReadFrame(); !errors.Is(err, ErrResultTooShort) {
+			t.Fatalf("Expected ErrResultTooShort, got %v.\n", err)
+		}
+	}
+
+	// 协议标识错误
+	{
+		bad := append([]uint8{}, frame...)
+		bad[3] = 0x01
+		fr := NewTCPFrameReader(io.MultiReader(bytes.NewReader(bad), bytes.NewReader(frame)))
+		if _, err := fr.ReadFrame(); !errors.Is(err, ErrResultTcpProtocol) {
+			t.Fatalf("Expected ErrResultTcpProtocol, got %v.\n", err)
+		}
+
+		// 错误的数据已被丢弃, 之后的帧可以正常读取
+		if got, err := fr.ReadFrame(); err != nil || !bytes.Equal(got, frame) {
+			t.Fatalf("Expected next frame after error, got %s, %v.\n", strFromHex(got), err)
+		}
+	}
+
+	// 长度字段超出 ADU 最大长度
+	{
+		bad := append([]uint8{}, frame...)
+		bad[4] = 0x01
+		fr := NewTCPFrameReader(bytes.NewReader(bad))
+		if _, err := fr.ReadFrame(); !errors.Is(err, ErrResultLength) {
+			t.Fatalf("Expected ErrResultLength, got %v.\n", err)
+		}
+	}
+
+	// 丢弃噪声后重新同步
+	{
+		noisy := append([]uint8{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, frame...)
+		fr := NewTCPFrameReader(bytes.NewReader(noisy))
+		fr.SetResync(true)
+		got, err := fr.ReadFrame()
+		if err != nil || !bytes.Equal(got, frame) {
+			t.Fatalf("Resync failed: got %s, %v.\n", strFromHex(got), err)
+		}
+	}
+}
@@ -0,0 +1,808 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 结构体字段错误 (Marshal/MarshalArg/Unmarshal)
+// Err 为 ErrResultStruct/ErrResultFieldOverlap/ErrResultFieldRange, 可通过 errors.Is 判断
+type FieldError struct {
+	Field string // 字段名
+	Err   error  // 错误原因
+	Msg   string // 详细信息
+}
+
+func (e *FieldError) Error() string {
+	return "field " + e.Field + ": " + e.Err.Error() + " (" + e.Msg + ")"
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// 寄存器值的原始类型
+type valueKind uint8
+
+const (
+	kindBool valueKind = iota + 1 // 线圈/离散量输入, 或寄存器中的位
+	kindUint16
+	kindInt16
+	kindUint32
+	kindInt32
+	kindFloat32
+	kindUint64
+	kindInt64
+	kindFloat64
+	kindString // 每个寄存器 2 个字符
+	kindBCD16  // 4 位 BCD 码
+	kindBCD32  // 8 位 BCD 码
+)
+
+var kindNames = map[string]valueKind{
+	"bool":    kindBool,
+	"uint16":  kindUint16,
+	"int16":   kindInt16,
+	"uint32":  kindUint32,
+	"int32":   kindInt32,
+	"float32": kindFloat32,
+	"uint64":  kindUint64,
+	"int64":   kindInt64,
+	"float64": kindFloat64,
+	"string":  kindString,
+	"bcd16":   kindBCD16,
+	"bcd32":   kindBCD32,
+}
+
+var tableNames = map[string]Table{
+	"coil":     TableCoil,
+	"discrete": TableDiscrete,
+	"input":    TableInput,
+	"hold":     TableHold,
+}
+
+var padNames = map[string]uint8{
+	"nul":   PadNul,
+	"space": PadSpace,
+}
+
+var orderNames = map[string]WordOrder{
+	"ABCD": OrderABCD,
+	"CDAB": OrderCDAB,
+	"BADC": OrderBADC,
+	"DCBA": OrderDCBA,
+}
+
+// 原始类型占用的寄存器数量
+func (k valueKind) regs() uint16 {
+	switch k {
+	case kindUint32, kindInt32, kindFloat32, kindBCD32:
+		return 2
+	case kindUint64, kindInt64, kindFloat64:
+		return 4
+	}
+	return 1
+}
+
+func (k valueKind) isFloat() bool {
+	return k == kindFloat32 || k == kindFloat64
+}
+
+// 整数原始类型的取值范围
+func (k valueKind) limits() (min int64, max uint64) {
+	switch k {
+	case kindInt16:
+		return math.MinInt16, math.MaxInt16
+	case kindUint32:
+		return 0, math.MaxUint32
+	case kindInt32:
+		return math.MinInt32, math.MaxInt32
+	case kindUint64:
+		return 0, math.MaxUint64
+	case kindInt64:
+		return math.MinInt64, math.MaxInt64
+	case kindBCD16:
+		return 0, 9999
+	case kindBCD32:
+		return 0, 99999999
+	}
+	return 0, math.MaxUint16
+}
+
+// 寄存器值的编码参数
+type regCodec struct {
+	table Table     // 数据表
+	addr  uint16    // 起始地址
+	kind  valueKind // 原始类型
+	order WordOrder // 字/字节顺序
+	scale float64   // 缩放系数 (工程值 = 原始值 * scale, 0 表示不缩放)
+	bit   int       // 位字段的起始位 (-1 表示不是位字段)
+	bits  uint      // 位字段的宽度
+	pad   uint8     // 字符串填充字符
+	size  uint16    // 单个值占用的寄存器数量 (位数据表为位数)
+}
+
+// 整数原始值的取值范围 (含位字段)
+func (c *regCodec) limits() (int64, uint64) {
+	if c.bit >= 0 {
+		return 0, 1<<c.bits - 1
+	}
+	return c.kind.limits()
+}
+
+// 位字段参数
+func (c *regCodec) bitField() BitField {
+	return BitField{Bit: uint8(c.bit), Len: uint8(c.bits)}
+}
+
+// 读取单个值到 dst, off 为相对于 Arg.GetRegAddr() 的偏移 (寄存器或位)
+func (c *regCodec) get(arg *groArg, off int, dst reflect.Value) error {
+	if c.table.isBit() {
+		dst.SetBool(arg.all[off/8]&(1<<(off%8)) != 0)
+		return nil
+	}
+
+	switch c.kind {
+	case kindString:
+		dst.SetString(arg.GetString(off, int(c.size), c.order.swapBytes()))
+		return nil
+	case kindFloat32:
+		return setFloat(dst, float64(arg.GetFloat32(off, c.order)), c.scale)
+	case kindFloat64:
+		return setFloat(dst, arg.GetFloat64(off, c.order), c.scale)
+	}
+
+	var u uint64
+	switch {
+	case c.bit >= 0:
+		v, err := arg.GetBitField(off, c.bitField(), c.order)
+		if err != nil {
+			return err
+		}
+		u = uint64(v)
+		if dst.Kind() == reflect.Bool {
+			dst.SetBool(u != 0)
+			return nil
+		}
+	case c.kind == kindBCD16:
+		v, err := arg.GetBCD16(off)
+		if err != nil {
+			return err
+		}
+		u = uint64(v)
+	case c.kind == kindBCD32:
+		v, err := arg.GetBCD32(off, c.order)
+		if err != nil {
+			return err
+		}
+		u = uint64(v)
+	case c.kind.regs() == 2:
+		u = uint64(arg.GetUint32(off, c.order))
+	case c.kind.regs() == 4:
+		u = arg.GetUint64(off, c.order)
+	default:
+		u = uint64(arg.GetU16(off*2, c.order.byteOrder()))
+	}
+
+	// 有符号类型按位宽扩展符号位
+	var i int64
+	signed := false
+	switch c.kind {
+	case kindInt16:
+		i, signed = int64(int16(u)), true
+	case kindInt32:
+		i, signed = int64(int32(u)), true
+	case kindInt64:
+		i, signed = int64(u), true
+	}
+	return setInt(dst, i, u, signed, c.scale)
+}
+
+// 写入单个值, off 为相对于 Arg.GetRegAddr() 的偏移 (寄存器或位)
+func (c *regCodec) put(arg *groArg, off int, src reflect.Value) error {
+	if c.table.isBit() {
+		if src.Bool() {
+			arg.all[off/8] |= 1 << (off % 8)
+		} else {
+			arg.all[off/8] &^= 1 << (off % 8)
+		}
+		return nil
+	}
+
+	switch c.kind {
+	case kindString:
+		if err := arg.SetString(off, int(c.size), src.String(), c.pad, c.order.swapBytes()); err != nil {
+			return ErrResultFieldRange
+		}
+		return nil
+	case kindFloat32:
+		arg.SetFloat32(off, float32(getFloat(src, c.scale)), c.order)
+		return nil
+	case kindFloat64:
+		arg.SetFloat64(off, getFloat(src, c.scale), c.order)
+		return nil
+	}
+
+	// 检查取值范围
+	min, max := c.limits()
+	var u uint64
+	switch src.Kind() {
+	case reflect.Bool:
+		if src.Bool() {
+			u = 1
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := src.Int()
+		if i < min || (i > 0 && uint64(i) > max) {
+			return ErrResultFieldRange
+		}
+		u = uint64(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u = src.Uint(); u > max {
+			return ErrResultFieldRange
+		}
+	default:
+		f := math.Round(getFloat(src, c.scale))
+		if f < float64(min) || f >= float64(max)+1 || math.IsNaN(f) {
+			return ErrResultFieldRange
+		}
+		if f < 0 {
+			u = uint64(int64(f))
+		} else {
+			u = uint64(f)
+		}
+	}
+
+	switch {
+	case c.bit >= 0:
+		return arg.SetBitField(off, c.bitField(), uint16(u), c.order)
+	case c.kind == kindBCD16:
+		return arg.SetBCD16(off, uint16(u))
+	case c.kind == kindBCD32:
+		return arg.SetBCD32(off, uint32(u), c.order)
+	case c.kind.regs() == 2:
+		arg.SetUint32(off, uint32(u), c.order)
+	case c.kind.regs() == 4:
+		arg.SetUint64(off, u, c.order)
+	default:
+		c.order.byteOrder().PutUint16(arg.all[off*2:off*2+2], uint16(u))
+	}
+	return nil
+}
+
+// 设置浮点数字段 (整数字段不允许缩放, 由标签检查保证)
+func setFloat(dst reflect.Value, f, scale float64) error {
+	if scale != 0 {
+		f *= scale
+	}
+	if dst.Kind() == reflect.Float32 && dst.OverflowFloat(f) {
+		return ErrResultFieldRange
+	}
+	dst.SetFloat(f)
+	return nil
+}
+
+// 设置整数/浮点数字段
+func setInt(dst reflect.Value, i int64, u uint64, signed bool, scale float64) error {
+	switch dst.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !signed {
+			if u > math.MaxInt64 {
+				return ErrResultFieldRange
+			}
+			i = int64(u)
+		}
+		if dst.OverflowInt(i) {
+			return ErrResultFieldRange
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if signed {
+			if i < 0 {
+				return ErrResultFieldRange
+			}
+			u = uint64(i)
+		}
+		if dst.OverflowUint(u) {
+			return ErrResultFieldRange
+		}
+		dst.SetUint(u)
+	default:
+		f := float64(u)
+		if signed {
+			f = float64(i)
+		}
+		return setFloat(dst, f, scale)
+	}
+	return nil
+}
+
+// 获取数值字段的值 (除以缩放系数)
+func getFloat(src reflect.Value, scale float64) float64 {
+	var f float64
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(src.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f = float64(src.Uint())
+	default:
+		f = src.Float()
+	}
+	if scale != 0 {
+		f /= scale
+	}
+	return f
+}
+
+// 结构体字段
+type structField struct {
+	regCodec
+	name  string // 字段名
+	index int    // 字段序号
+	count int    // 元素数量 (数组/切片, 单个值为 1)
+	slice bool   // 是否为切片
+}
+
+// 占用的地址范围 [start, end)
+func (f *structField) span() (start, end int) {
+	return int(f.addr), int(f.addr) + int(f.size)*f.count
+}
+
+var structCache sync.Map // reflect.Type -> []structField
+
+// 解析结构体的字段标签 (结果按类型缓存)
+func structFields(t reflect.Type) ([]structField, error) {
+	if fields, ok := structCache.Load(t); ok {
+		return fields.([]structField), nil
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("modbus")
+		if !ok || tag == "-" {
+			continue
+		}
+		if !sf.IsExported() {
+			return nil, &FieldError{Field: sf.Name, Err: ErrResultStruct, Msg: "unexported field"}
+		}
+		f, msg := parseField(sf, tag)
+		if msg != "" {
+			return nil, &FieldError{Field: sf.Name, Err: ErrResultStruct, Msg: msg}
+		}
+		f.index = i
+
+		// 检查同一数据表中的字段是否重叠 (同一寄存器中不同的位字段不视为重叠)
+		for j := range fields {
+			o := &fields[j]
+			s1, e1 := f.span()
+			s2, e2 := o.span()
+			if o.table != f.table || s1 >= e2 || s2 >= e1 {
+				continue
+			}
+			if f.bit >= 0 && o.bit >= 0 && (f.bit+int(f.bits) <= o.bit || o.bit+int(o.bits) <= f.bit) {
+				continue
+			}
+			return nil, &FieldError{Field: f.name, Err: ErrResultFieldOverlap, Msg: "overlaps " + o.name}
+		}
+		fields = append(fields, f)
+	}
+
+	structCache.Store(t, fields)
+	return fields, nil
+}
+
+// 解析字段标签, 如 `modbus:"addr=100,type=float32,order=CDAB,scale=0.1"`
+// 标签项:
+//   - addr:  起始地址 (必需, 支持 0x 前缀)
+//   - table: 数据表 coil/discrete/input/hold (bool 默认为 coil, 其他默认为 hold)
+//   - type:  原始类型 bool/uint16/int16/uint32/int32/float32/uint64/int64/float64/string/bcd16/bcd32 (默认依据字段类型)
+//   - order: 字/字节顺序 ABCD/CDAB/BADC/DCBA (默认 ABCD, 字符串与位字段仅区分是否交换字节)
+//   - scale: 缩放系数, 工程值 = 原始值 * scale (字段须为浮点数)
+//   - bit:   寄存器中位字段的起始位 (0~15)
+//   - len:   字符串的寄存器数量, 或位字段的宽度 (默认 1)
+//   - pad:   字符串的填充字符 nul/space (默认 nul)
+//   - count: 切片的元素数量 (数组依据数组长度)
+//
+// 返回值: 标签错误时返回错误信息
+func parseField(sf reflect.StructField, tag string) (structField, string) {
+	f := structField{name: sf.Name, count: 1}
+	f.bit = -1
+
+	hasAddr, hasPad, length := false, false, 0
+	for _, item := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(item), "=")
+		var ok bool
+		switch key {
+		case "addr":
+			var n uint64
+			n, ok = parseUint(value, math.MaxUint16)
+			f.addr, hasAddr = uint16(n), ok
+		case "table":
+			f.table, ok = tableNames[value]
+		case "type":
+			f.kind, ok = kindNames[value]
+		case "order":
+			f.order, ok = orderNames[strings.ToUpper(value)]
+		case "scale":
+			var err error
+			f.scale, err = strconv.ParseFloat(value, 64)
+			ok = err == nil && f.scale != 0 && !math.IsInf(f.scale, 0) && !math.IsNaN(f.scale)
+		case "bit":
+			var n uint64
+			n, ok = parseUint(value, 15)
+			f.bit = int(n)
+		case "len":
+			var n uint64
+			n, ok = parseUint(value, maxReadHoldBytes/2)
+			length, ok = int(n), ok && n > 0
+		case "pad":
+			f.pad, ok = padNames[value]
+			hasPad = ok
+		case "count":
+			var n uint64
+			n, ok = parseUint(value, math.MaxUint16)
+			f.count, ok = int(n), ok && n > 0
+		}
+		if !ok {
+			return f, "invalid tag item " + strconv.Quote(item)
+		}
+	}
+	if !hasAddr {
+		return f, "missing addr"
+	}
+
+	// 数组/切片
+	t := sf.Type
+	switch t.Kind() {
+	case reflect.Array:
+		f.count = t.Len()
+		t = t.Elem()
+	case reflect.Slice:
+		f.slice = true
+		t = t.Elem()
+	}
+	if f.count == 0 {
+		return f, "empty array"
+	}
+
+	// 原始类型
+	if f.kind == 0 {
+		for name, kind := range kindNames {
+			if t.Kind().String() == name {
+				f.kind = kind
+			}
+		}
+		if f.kind == 0 {
+			return f, "missing type for " + t.String()
+		}
+	}
+	if f.table == 0 {
+		f.table = TableHold
+		if f.kind == kindBool && f.bit < 0 {
+			f.table = TableCoil
+		}
+	}
+
+	// 检查原始类型与字段类型是否匹配
+	goKind := t.Kind()
+	isNumber := goKind >= reflect.Int && goKind <= reflect.Float64 && goKind != reflect.Uintptr
+	isFloat := goKind == reflect.Float32 || goKind == reflect.Float64
+	switch {
+	case f.kind == kindBool:
+		if goKind != reflect.Bool {
+			return f, "bool requires a bool field"
+		}
+	case f.kind == kindString:
+		if goKind != reflect.String {
+			return f, "string requires a string field"
+		}
+	case f.bit >= 0:
+		if !isNumber && goKind != reflect.Bool {
+			return f, "bit field requires a numeric or bool field"
+		}
+	case !isNumber:
+		return f, f.kind.String() + " requires a numeric field"
+	case (f.scale != 0 || f.kind.isFloat()) && !isFloat:
+		return f, "scale and float types require a float field"
+	}
+	if f.bit >= 0 && f.count > 1 {
+		return f, "bit field cannot be an array"
+	}
+	if msg := f.init(length, hasPad); msg != "" {
+		return f, msg
+	}
+	return f, f.checkEnd()
+}
+
+// 检查原始类型与数据表、位字段等参数是否匹配, 并计算单个值占用的寄存器数量
+// length 为字符串的寄存器数量或位字段的宽度 (0 表示未指定)
+// 返回值: 参数错误时返回错误信息
+func (c *regCodec) init(length int, hasPad bool) string {
+	switch {
+	case c.table.isBit():
+		if c.kind != kindBool || c.bit >= 0 || c.scale != 0 || length != 0 || c.order != OrderABCD || hasPad {
+			return c.table.String() + " requires plain type bool"
+		}
+		c.size = 1
+		return ""
+	case c.kind == kindBool:
+		if c.bit < 0 {
+			return "bool in registers requires bit"
+		}
+		c.kind, c.bits = kindUint16, 1
+	case c.kind == kindString:
+		if length == 0 || c.bit >= 0 || c.scale != 0 {
+			return "string requires len and cannot have bit or scale"
+		}
+		c.size = uint16(length)
+		return ""
+	case c.bit >= 0:
+		if c.kind != kindUint16 {
+			return "bit field requires type uint16"
+		}
+		c.bits = uint(max(length, 1))
+		if c.bit+int(c.bits) > 16 {
+			return "bit field exceeds 16 bits"
+		}
+	}
+	if hasPad {
+		return "pad is only valid for strings"
+	}
+	if length != 0 && c.bit < 0 {
+		return "len is only valid for strings and bit fields"
+	}
+	c.size = c.kind.regs()
+	return ""
+}
+
+// 检查结束地址是否超出 0xFFFF
+func (f *structField) checkEnd() string {
+	if _, end := f.span(); end > math.MaxUint16+1 {
+		return "address exceeds 0xFFFF"
+	}
+	return ""
+}
+
+func (k valueKind) String() string {
+	for name, kind := range kindNames {
+		if kind == k {
+			return name
+		}
+	}
+	return "unknown type"
+}
+
+func parseUint(s string, max uint64) (uint64, bool) {
+	n, err := strconv.ParseUint(s, 0, 64)
+	return n, err == nil && n <= max
+}
+
+// 获取寄存器值所属的数据表与数量
+func argTable(arg *groArg) (Table, uint16, bool) {
+	switch arg.GetFuncCode() {
+	case FuncCodeReadCoil, FuncCodeWriteCoils:
+		return TableCoil, arg.GetRegLen(), true
+	case FuncCodeWriteCoil:
+		return TableCoil, 1, true
+	case FuncCodeReadDiscrete:
+		return TableDiscrete, arg.GetRegLen(), true
+	case FuncCodeReadHold, FuncCodeWriteHolds:
+		return TableHold, arg.GetRegLen(), true
+	case FuncCodeWriteHold:
+		return TableHold, 1, true
+	case FuncCodeReadInput:
+		return TableInput, arg.GetRegLen(), true
+	}
+	return 0, 0, false
+}
+
+// 获取寄存器值的数据表与范围 [base, base+count), 并检查寄存器值长度 (写入时扩展)
+func argSpan(arg *groArg, isWrite bool) (table Table, base, count int, err error) {
+	table, n, ok := argTable(arg)
+	if !ok {
+		return 0, 0, 0, ErrResultFuncCode
+	}
+	need := int(n) * 2
+	if table.isBit() {
+		need = (int(n) + 7) / 8
+	}
+	if isWrite {
+		arg.grow(need)
+	} else if len(arg.all) < need {
+		return 0, 0, 0, ErrResultLength
+	}
+	return table, int(arg.GetRegAddr()), int(n), nil
+}
+
+// 检查地址范围 [start, end) 是否位于 [base, base+count) 之内
+// 返回值: 完全位于之内时返回 true, 完全位于之外时返回 false, 跨越边界时返回错误信息
+func checkSpan(start, end, base, count int) (bool, string) {
+	if end <= base || start >= base+count {
+		return false, ""
+	}
+	if start < base || end > base+count {
+		return false, "address " + strconv.Itoa(start) + "~" + strconv.Itoa(end-1) +
+			" not within " + strconv.Itoa(base) + "~" + strconv.Itoa(base+count-1)
+	}
+	return true, ""
+}
+
+// 遍历结构体中位于寄存器值范围内的字段
+// 数据表依据功能码确定, 范围为 [GetRegAddr, GetRegAddr+GetRegLen), 完全位于范围之外的字段被忽略
+func walkStruct(arg *groArg, v any, isWrite bool, fn func(f *structField, off int, fv reflect.Value) error) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrResultStruct
+	}
+	fields, err := structFields(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	table, base, count, err := argSpan(arg, isWrite)
+	if err != nil {
+		return err
+	}
+
+	for i := range fields {
+		f := &fields[i]
+		start, end := f.span()
+		if f.table != table {
+			continue
+		}
+		in, msg := checkSpan(start, end, base, count)
+		if msg != "" {
+			return &FieldError{Field: f.name, Err: ErrResultFieldRange, Msg: msg}
+		}
+		if !in {
+			continue
+		}
+
+		fv := rv.Elem().Field(f.index)
+		if f.slice {
+			if isWrite && fv.Len() != f.count {
+				return &FieldError{Field: f.name, Err: ErrResultFieldRange, Msg: "slice length " + strconv.Itoa(fv.Len()) + " != count " + strconv.Itoa(f.count)}
+			}
+			if !isWrite && fv.Len() != f.count {
+				fv.Set(reflect.MakeSlice(fv.Type(), f.count, f.count))
+			}
+		}
+		if err := fn(f, start-base, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 依据结构体标签, 将寄存器值 (如解析的响应报文) 解码到结构体 v (指针)
+// 标签格式参见 parseField, 如 `modbus:"addr=100,type=float32,order=CDAB,scale=0.1"`
+// 数据表依据 Arg 的功能码确定, 仅解码完整位于 [GetRegAddr, GetRegAddr+GetRegLen) 范围内的字段
+// 返回值: 字段跨越范围边界、字段重叠或标签错误时返回 *FieldError
+func Unmarshal(arg *groArg, v any) error {
+	return walkStruct(arg, v, false, func(f *structField, off int, fv reflect.Value) error {
+		return forElems(f, off, fv, func(off int, ev reflect.Value) error {
+			return f.get(arg, off, ev)
+		})
+	})
+}
+
+// 依据结构体标签, 将结构体 v (指针) 编码到已有的寄存器值中 (如修改读取的保持寄存器后写回)
+// 调用前需通过 Arg.Init 设置功能码、起始地址与数量, 范围之外的字段被忽略, 未被字段覆盖的寄存器值保持不变
+// 返回值: 字段跨越范围边界、值超出原始类型范围、字段重叠或标签错误时返回 *FieldError
+func MarshalArg(arg *groArg, v any) error {
+	return walkStruct(arg, v, true, func(f *structField, off int, fv reflect.Value) error {
+		return forElems(f, off, fv, func(off int, ev reflect.Value) error {
+			return f.put(arg, off, ev)
+		})
+	})
+}
+
+// 寄存器块 (数据表中连续地址的值)
+type Block struct {
+	Span         // 地址范围
+	Data []uint8 // 寄存器值 (大端字节序); 位数据表按位打包, 第 0 位为 Addr
+}
+
+// 依据结构体标签, 将结构体 v (指针) 编码为寄存器块
+// 可写的数据表 (线圈、保持寄存器) 各生成一个寄存器块, 范围为该数据表中全部字段的最小地址范围, 字段之间的空隙为 0;
+// 离散量输入与输入寄存器的字段被忽略
+// 返回值: 值超出原始类型范围、字段重叠或标签错误时返回 *FieldError, 范围超出 0xFFFF 个时返回 ErrResultRegLen
+func Marshal(v any) ([]Block, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return nil, ErrResultStruct
+	}
+	fields, err := structFields(rv.Elem().Type())
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []Block
+	for _, table := range []Table{TableCoil, TableHold} {
+		start, end := -1, 0
+		for i := range fields {
+			if f := &fields[i]; f.table == table {
+				s, e := f.span()
+				if start < 0 || s < start {
+					start = s
+				}
+				end = max(end, e)
+			}
+		}
+		if start < 0 {
+			continue
+		}
+		if end-start > math.MaxUint16 {
+			return nil, ErrResultRegLen
+		}
+
+		b := Block{Span: Span{Table: table, Addr: uint16(start), Len: uint16(end - start)}}
+		var arg groArg
+		b.Init(&arg)
+		if err := MarshalArg(&arg, v); err != nil {
+			return nil, err
+		}
+		b.Data = arg.GetU8s()
+		blocks = append(blocks, b)
+	}
+	return blocks, nil
+}
+
+// 写请求的功能码 (写多个线圈/写多个保持寄存器)
+func (b *Block) FuncCode() uint8 {
+	if b.Table == TableCoil {
+		return FuncCodeWriteCoils
+	}
+	return FuncCodeWriteHolds
+}
+
+// 初始化写请求参数 (功能码、起始地址、数量与寄存器值, 寄存器值与 b.Data 共享内存)
+// 数量超出单个写请求的最大数量时封装请求报文将返回错误
+func (b *Block) Init(arg *groArg) {
+	arg.Init(b.FuncCode(), b.Addr, b.Len)
+	if b.Data != nil {
+		arg.SetU8s(b.Data)
+	}
+}
+
+// 依据结构体标签, 将解析的报文 (Arg) 解码到结构体 v (指针), 同 Unmarshal(&m.Arg, v)
+func (m *Modbus) Unmarshal(v any) error {
+	return Unmarshal(&m.Arg, v)
+}
+
+// 依据结构体标签, 将结构体 v (指针) 编码为写请求 (Arg), 同 MarshalArg(&m.Arg, v)
+// 调用前需通过 Arg.Init 或 Block.Init 设置功能码、起始地址与数量
+func (m *Modbus) Marshal(v any) error {
+	return MarshalArg(&m.Arg, v)
+}
+
+// 遍历字段的元素 (单个值或数组/切片)
+func forElems(f *structField, off int, fv reflect.Value, fn func(off int, ev reflect.Value) error) error {
+	if f.count == 1 && fv.Kind() != reflect.Array && fv.Kind() != reflect.Slice {
+		return wrapField(f, fn(off, fv))
+	}
+	for i := 0; i < f.count; i++ {
+		if err := fn(off+i*int(f.size), fv.Index(i)); err != nil {
+			return wrapField(f, err)
+		}
+	}
+	return nil
+}
+
+func wrapField(f *structField, err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == ErrResultRegValue {
+		return &FieldError{Field: f.name, Err: err, Msg: "invalid " + f.kind.String() + " value"}
+	}
+	return &FieldError{Field: f.name, Err: err, Msg: "value out of range for " + f.kind.String()}
+}
@@ -0,0 +1,276 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testDevice struct {
+	Voltage float64   `modbus:"addr=100,type=uint16,scale=0.1"`
+	Power   float32   `modbus:"addr=101,order=CDAB"`
+	Energy  uint64    `modbus:"addr=103,order=DCBA"`
+	Temp    int16     `modbus:"addr=107"`
+	Alarm   bool      `modbus:"addr=108,bit=0"`
+	Mode    uint8     `modbus:"addr=108,bit=4,len=3,type=uint16"`
+	Name    string    `modbus:"addr=109,len=3,order=BADC"`
+	Limits  [2]int32  `modbus:"addr=112"`
+	Samples []float64 `modbus:"addr=116,type=int16,count=2,scale=0.5"`
+	Running bool      `modbus:"addr=0"`
+	Faults  [3]bool   `modbus:"addr=1,table=coil"`
+	Door    bool      `modbus:"addr=0x10,table=discrete"`
+	Ignored int
+}
+
+func TestMarshal(t *testing.T) {
+	in := testDevice{
+		Voltage: 230.5,
+		Power:   1.0,
+		Energy:  0x0102030405060708,
+		Temp:    -40,
+		Alarm:   true,
+		Mode:    5,
+		Name:    "AB1",
+		Limits:  [2]int32{-1, 100000},
+		Samples: []float64{-1.5, 2},
+		Running: true,
+		Faults:  [3]bool{false, true, true},
+		Door:    true,
+		Ignored: 1,
+	}
+
+	var arg groArg
+	arg.Init(FuncCodeWriteHolds, 100, 18)
+	if err := MarshalArg(&arg, &in); err != nil {
+		t.Fatalf("Failed to marshal: %v.\n", err)
+	}
+	expect := strToHex("0901" + "0000 3F80" + "0807 0605 0403 0201" + "FFD8" + "0051" +
+		"4241 0031 0000" + "FFFF FFFF 0001 86A0" + "FFFD 0004")
+	if !bytes.Equal(arg.GetU8s(), expect) {
+		t.Fatalf("Registers mismatch:\nExpected = %x\nActual   = %x.\n", expect, arg.GetU8s())
+	}
+
+	var out testDevice
+	arg.Init(FuncCodeReadHold, 100, 18)
+	arg.SetU8s(expect)
+	if err := Unmarshal(&arg, &out); err != nil {
+		t.Fatalf("Failed to unmarshal: %v.\n", err)
+	}
+
+	// 线圈与离散量输入
+	arg.Init(FuncCodeWriteCoils, 0, 4)
+	if err := MarshalArg(&arg, &in); err != nil {
+		t.Fatalf("Failed to marshal coils: %v.\n", err)
+	}
+	if got := arg.GetU8s(); !bytes.Equal(got, []uint8{0x0D}) {
+		t.Fatalf("Coils mismatch: %x.\n", got)
+	}
+	arg.Init(FuncCodeReadCoil, 0, 4)
+	arg.SetU8s([]uint8{0x0D})
+	if err := Unmarshal(&arg, &out); err != nil {
+		t.Fatalf("Failed to unmarshal coils: %v.\n", err)
+	}
+	arg.Init(FuncCodeReadDiscrete, 0x10, 1)
+	arg.SetU8s([]uint8{0x01})
+	if err := Unmarshal(&arg, &out); err != nil {
+		t.Fatalf("Failed to unmarshal discretes: %v.\n", err)
+	}
+
+	in.Ignored = 0
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("Round trip mismatch:\nExpected = %+v\nActual   = %+v.\n", in, out)
+	}
+
+	// 范围之外的字段被忽略, 未覆盖的寄存器值保持不变
+	arg.Init(FuncCodeWriteHolds, 107, 2)
+	arg.SetU16s([]uint16{0x0000, 0xFF00}, binary.BigEndian)
+	if err := MarshalArg(&arg, &in); err != nil {
+		t.Fatalf("Failed to marshal partial: %v.\n", err)
+	}
+	if got := arg.GetU8s(); !bytes.Equal(got, strToHex("FFD8 FF51")) {
+		t.Fatalf("Partial registers mismatch: %x.\n", got)
+	}
+
+	// BCD 与空格填充字符串
+	type meter struct {
+		Count uint16  `modbus:"addr=0,type=bcd16"`
+		Total float64 `modbus:"addr=1,type=bcd32,order=CDAB,scale=0.01"`
+		Model string  `modbus:"addr=3,len=2,pad=space"`
+	}
+	m := meter{Count: 42, Total: 123456.78, Model: "M1"}
+	arg.Init(FuncCodeWriteHolds, 0, 5)
+	if err := MarshalArg(&arg, &m); err != nil {
+		t.Fatalf("Failed to marshal meter: %v.\n", err)
+	}
+	if got := arg.GetU8s(); !bytes.Equal(got, strToHex("0042 5678 1234 4D31 2020")) {
+		t.Fatalf("Meter registers mismatch: %x.\n", got)
+	}
+	var mout meter
+	if err := Unmarshal(&arg, &mout); err != nil || mout != m {
+		t.Fatalf("Meter round trip mismatch: %+v, %v.\n", mout, err)
+	}
+
+	// 编码为寄存器块 (线圈与保持寄存器各一个, 离散量输入被忽略)
+	blocks, err := Marshal(&in)
+	if err != nil {
+		t.Fatalf("Failed to marshal blocks: %v.\n", err)
+	}
+	if len(blocks) != 2 || blocks[0].Span != (Span{Table: TableCoil, Addr: 0, Len: 4}) || !bytes.Equal(blocks[0].Data, []uint8{0x0D}) ||
+		blocks[1].Span != (Span{Table: TableHold, Addr: 100, Len: 18}) || !bytes.Equal(blocks[1].Data, expect) {
+		t.Fatalf("Blocks mismatch: %+v.\n", blocks)
+	}
+
+	// 通过 Modbus 实例编码写请求并在从站解码
+	master, slave := testPair(ProtocolTCP)
+	blocks[1].Init(&master.Arg)
+	if err := testExchange(t, master, slave, "", "10 0064 0012", nil); err != nil {
+		t.Fatalf("Failed to write block: %v.\n", err)
+	}
+	out = testDevice{}
+	if err := slave.Unmarshal(&out); err != nil || out.Energy != in.Energy || out.Name != in.Name {
+		t.Fatalf("Slave unmarshal mismatch: %+v, %v.\n", out, err)
+	}
+	master.Arg.Init(FuncCodeWriteHolds, 107, 1)
+	if err := master.Marshal(&in); err != nil || !bytes.Equal(master.Arg.GetU8s(), strToHex("FFD8")) {
+		t.Fatalf("Modbus marshal mismatch: %x, %v.\n", master.Arg.GetU8s(), err)
+	}
+
+	// 字节交换的位字段
+	type status struct {
+		Run  bool  `modbus:"addr=0,bit=0,order=BADC"`
+		Mode uint8 `modbus:"addr=0,bit=12,len=4,type=uint16,order=DCBA"`
+	}
+	arg.Init(FuncCodeWriteHolds, 0, 1)
+	if err := MarshalArg(&arg, &status{Run: true, Mode: 9}); err != nil {
+		t.Fatalf("Failed to marshal status: %v.\n", err)
+	}
+	if got := arg.GetU8s(); !bytes.Equal(got, strToHex("0190")) {
+		t.Fatalf("Status registers mismatch: %x.\n", got)
+	}
+	var sout status
+	if err := Unmarshal(&arg, &sout); err != nil || sout != (status{Run: true, Mode: 9}) {
+		t.Fatalf("Status round trip mismatch: %+v, %v.\n", sout, err)
+	}
+
+	// 解析响应后的寄存器值引用接收的报文, 写入时不修改报文
+	master, slave = testPair(ProtocolTCP)
+	master.Arg.Init(FuncCodeReadHold, 0, 5)
+	req, _ := master.AppendRequest(nil)
+	if err := slave.ParseRequest(req); err != nil {
+		t.Fatalf("Failed to parse request: %v.\n", err)
+	}
+	slave.Arg.SetU8s(strToHex("0042 5678 1234 4D31 2020"))
+	rsp, _ := slave.AppendResponse(nil)
+	saved := bytes.Clone(rsp)
+	if err := master.ParseResponse(rsp); err != nil {
+		t.Fatalf("Failed to parse response: %v.\n", err)
+	}
+	if err := MarshalArg(&master.Arg, &meter{Count: 1}); err != nil {
+		t.Fatalf("Failed to marshal into response: %v.\n", err)
+	}
+	if !bytes.Equal(rsp, saved) {
+		t.Fatalf("Response modified:\nExpected = %x\nActual   = %x.\n", saved, rsp)
+	}
+	if got := master.Arg.GetU8s(); !bytes.Equal(got, strToHex("0001 0000 0000 2020 2020")) {
+		t.Fatalf("Registers mismatch: %x.\n", got)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	var arg groArg
+
+	// 字段跨越寄存器范围边界
+	arg.Init(FuncCodeReadHold, 101, 4)
+	arg.SetU8s(make([]uint8, 8))
+	var dev testDevice
+	var ferr *FieldError
+	if err := Unmarshal(&arg, &dev); !errors.Is(err, ErrResultFieldRange) || !errors.As(err, &ferr) || ferr.Field != "Energy" {
+		t.Fatalf("Expected ErrResultFieldRange for Energy, got %v.\n", err)
+	}
+
+	// 寄存器值过短
+	arg.Init(FuncCodeReadHold, 100, 4)
+	arg.SetU8s(make([]uint8, 6))
+	if err := Unmarshal(&arg, &dev); !errors.Is(err, ErrResultLength) {
+		t.Fatalf("Expected ErrResultLength, got %v.\n", err)
+	}
+
+	// 值超出原始类型范围
+	arg.Init(FuncCodeWriteHolds, 100, 1)
+	dev.Voltage = 7000
+	if err := MarshalArg(&arg, &dev); !errors.Is(err, ErrResultFieldRange) {
+		t.Fatalf("Expected ErrResultFieldRange for Voltage, got %v.\n", err)
+	}
+	dev.Name = "too long"
+	arg.Init(FuncCodeWriteHolds, 109, 3)
+	if err := MarshalArg(&arg, &dev); !errors.Is(err, ErrResultFieldRange) {
+		t.Fatalf("Expected ErrResultFieldRange for Name, got %v.\n", err)
+	}
+
+	// 字段重叠
+	var overlap struct {
+		A uint32 `modbus:"addr=10"`
+		B uint16 `modbus:"addr=11"`
+	}
+	arg.Init(FuncCodeReadHold, 10, 2)
+	arg.SetU8s(make([]uint8, 4))
+	if err := Unmarshal(&arg, &overlap); !errors.Is(err, ErrResultFieldOverlap) {
+		t.Fatalf("Expected ErrResultFieldOverlap, got %v.\n", err)
+	}
+	var bits struct {
+		A uint8 `modbus:"addr=10,bit=0,len=4,type=uint16"`
+		B bool  `modbus:"addr=10,bit=3"`
+	}
+	if err := Unmarshal(&arg, &bits); !errors.Is(err, ErrResultFieldOverlap) {
+		t.Fatalf("Expected ErrResultFieldOverlap for bit fields, got %v.\n", err)
+	}
+
+	// 标签错误
+	for _, v := range []any{
+		&struct {
+			A uint16 `modbus:"type=uint16"`
+		}{},
+		&struct {
+			A int `modbus:"addr=1"`
+		}{},
+		&struct {
+			A uint16 `modbus:"addr=1,scale=0.1"`
+		}{},
+		&struct {
+			A uint16 `modbus:"addr=1,table=coil"`
+		}{},
+		&struct {
+			A string `modbus:"addr=1"`
+		}{},
+		&struct {
+			A uint16 `modbus:"addr=0xFFFF,type=uint32"`
+		}{},
+		&struct {
+			A uint16 `modbus:"addr=1,bit=14,len=3"`
+		}{},
+		&struct {
+			A uint16 `modbus:"addr=1,unknown=1"`
+		}{},
+	} {
+		if err := Unmarshal(&arg, v); !errors.Is(err, ErrResultStruct) {
+			t.Fatalf("%T: expected ErrResultStruct, got %v.\n", v, err)
+		}
+	}
+	if err := Unmarshal(&arg, dev); !errors.Is(err, ErrResultStruct) {
+		t.Fatalf("Expected ErrResultStruct for non-pointer, got %v.\n", err)
+	}
+	if _, err := Marshal(dev); !errors.Is(err, ErrResultStruct) {
+		t.Fatalf("Expected ErrResultStruct for non-pointer marshal, got %v.\n", err)
+	}
+	arg.Init(FuncCodeReadFifo, 0, 0)
+	if err := Unmarshal(&arg, &dev); !errors.Is(err, ErrResultFuncCode) {
+		t.Fatalf("Expected ErrResultFuncCode, got %v.\n", err)
+	}
+}
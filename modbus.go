@@ -5,12 +5,19 @@
 
 package gromb
 
+import "time"
+
+// 发送请求报文并接收响应报文 (由调用方实现传输层)
+// 广播请求 (Head.IsBroadcast) 没有响应, 发送后应直接返回 nil
+type Transact func(req []uint8) ([]uint8, error)
+
 type Modbus struct {
 	Arg    groArg    // 处理寄存器值
 	Access groAccess // 数据访问控制器
 	Result groResult // 处理参数
 	Head   groHead   // 协议头参数
 	Box    groBox    // 处理报文盒子
+	Diag   groDiag   // 诊断计数器 (串行链路)
 }
 
 func New() *Modbus {
@@ -25,72 +32,183 @@ func (m *Modbus) Reset() {
 	m.Result.Reset()
 	m.Head.Reset()
 	m.Box.Reset()
+	m.Diag.Reset()
 }
 
+// 封装请求报文
+// 报文写入 b 的底层数组, 容量不足时调用方无法获取报文, 建议使用 AppendRequest
 func (m *Modbus) PackRequest(b []uint8) error {
-	b = b[:0]
-	m.Box.Init(&b, 1024)
-
-	switch m.Head.GetProtocol() {
-	case ProtocolRTU:
-		m.rtuPack(true)
-	case ProtocolAscii:
-		m.asciiPack(true)
-	case ProtocolTCP:
-		m.tcpPack(true)
-	default:
-		m.Result.SetResult(ErrResultProtocol)
-	}
-	return m.Result.GetResult()
+	_, err := m.AppendRequest(b[:0])
+	return err
 }
 
+// 封装响应报文
+// 报文写入 b 的底层数组, 容量不足时调用方无法获取报文, 建议使用 AppendResponse
 func (m *Modbus) PackResponse(b []uint8) error {
-	b = b[:0]
-	m.Box.Init(&b, 1024)
+	_, err := m.AppendResponse(b[:0])
+	return err
+}
+
+// 封装请求报文, 并追加到 dst 尾部
+// 返回值: 追加报文后的切片 (失败时返回原始的 dst)
+func (m *Modbus) AppendRequest(dst []uint8) ([]uint8, error) {
+	return m.appendPack(dst, true)
+}
+
+// 封装响应报文, 并追加到 dst 尾部
+// 返回值: 追加报文后的切片 (失败时返回原始的 dst)
+func (m *Modbus) AppendResponse(dst []uint8) ([]uint8, error) {
+	return m.appendPack(dst, false)
+}
+
+func (m *Modbus) appendPack(dst []uint8, isReq bool) ([]uint8, error) {
+	// 请求无需响应 (只听模式/广播)
+	if !isReq && m.Result.GetNoResponse() {
+		return dst, ErrResultNoResponse
+	}
+	// 广播仅支持写请求
+	if isReq && m.Head.IsBroadcast() && !m.Arg.IsRawMode() && !isBroadcastFunc(m.Arg.GetFuncCode()) {
+		return dst, ErrResultBroadcast
+	}
+
+	// 报文写入 dst 的剩余空间, 容量不足时由 append 扩容
+	m.Box.Init(dst[len(dst):], 1024)
 
 	switch m.Head.GetProtocol() {
-	case ProtocolRTU:
-		m.rtuPack(false)
-	case ProtocolAscii:
-		m.asciiPack(false)
+	case ProtocolRTU, ProtocolRtuOverTcp:
+		m.rtuPack(isReq)
+	case ProtocolAscii, ProtocolAsciiOverTcp:
+		m.asciiPack(isReq)
 	case ProtocolTCP:
-		m.tcpPack(false)
+		m.tcpPack(isReq)
 	default:
 		m.Result.SetResult(ErrResultProtocol)
 	}
-	return m.Result.GetResult()
+
+	if err := m.Result.GetResult(); err != nil {
+		return dst, err
+	}
+	return append(dst, m.Box.Bytes()[:m.Result.GetRetLen()]...), nil
 }
 
+// 解析请求报文
+// 请求需回复异常时返回 nil, 异常码保存在 Result.GetExcepCode() 中, 由 PackResponse 封装异常响应
+// 请求无需响应时 (如只听模式) 返回 ErrResultNoResponse
+// 广播写请求返回 nil, 调用方应执行请求, 但 PackResponse 返回 ErrResultNoResponse; 广播读请求返回 ErrResultBroadcast
 func (m *Modbus) ParseRequest(b []uint8) error {
-	m.Box.Init(&b, uint16(len(b)))
+	m.Box.Init(b, uint16(len(b)))
 	m.Result.Reset()
 
 	switch m.Head.GetProtocol() {
-	case ProtocolRTU:
+	case ProtocolRTU, ProtocolRtuOverTcp:
 		m.rtuParse(true)
-	case ProtocolAscii:
+	case ProtocolAscii, ProtocolAsciiOverTcp:
 		m.asciiParse(true)
 	case ProtocolTCP:
 		m.tcpParse(true)
 	default:
 		m.Result.SetResult(ErrResultProtocol)
 	}
+
+	if m.Result.GetResult() == nil && !m.Arg.IsRawMode() {
+		m.serveDiag()
+		if m.Result.GetNoResponse() {
+			m.Result.SetResult(ErrResultNoResponse)
+		} else {
+			m.serveWriteFile()
+			if m.Head.IsBroadcast() {
+				m.serveBroadcast()
+			}
+		}
+	}
 	return m.Result.GetResult()
 }
 
+// 解析响应报文
+// 异常响应返回 *ExceptionError, 可通过 errors.Is(err, ErrSlaveBusy) 等判断异常码
 func (m *Modbus) ParseResponse(b []uint8) error {
-	m.Box.Init(&b, uint16(len(b)))
+	m.Box.Init(b, uint16(len(b)))
 	m.Result.Reset()
 
 	switch m.Head.GetProtocol() {
-	case ProtocolRTU:
+	case ProtocolRTU, ProtocolRtuOverTcp:
 		m.rtuParse(false)
-	case ProtocolAscii:
+	case ProtocolAscii, ProtocolAsciiOverTcp:
 		m.asciiParse(false)
 	case ProtocolTCP:
 		m.tcpParse(false)
 	default:
 		m.Result.SetResult(ErrResultProtocol)
 	}
+
+	if m.Result.GetResult() == nil && m.Result.GetExcepCode() != ExcepNormal {
+		m.Result.SetResult(&ExceptionError{
+			FuncCode: m.Arg.GetFuncCode() &^ 0x80,
+			Code:     m.Result.GetExcepCode(),
+		})
+	}
 	return m.Result.GetResult()
 }
+
+// 解析 PDU 报文
+// length 为依据 ADU 帧计算的 PDU 长度, 仅用于原始 PDU 模式
+func (m *Modbus) parsePdu(box *groBox, length int, isReq bool) int {
+	// 透传协议没有流水号, 依据功能码关联请求与响应 (丢弃之前超时的请求的响应)
+	// 须在解析前检查, 避免丢弃的响应覆盖请求参数
+	if !isReq && m.Head.isOverTcp() && box.ThisSize() > 0 && box.GetU8(0)&^0x80 != m.Arg.GetFuncCode() {
+		m.Result.SetResult(ErrResultFuncCode)
+		return -1
+	}
+
+	if m.Arg.IsRawMode() {
+		return parseRaw(&m.Result, &m.Arg, box, length, isReq)
+	}
+
+	// 将解析限制在 PDU 范围内, 不含校验码等尾部数据 (如变长的诊断回送数据依据 ADU 长度确定)
+	full := box.buffer
+	if length >= 0 && int(box.last)+length < len(full) {
+		box.buffer = full[:int(box.last)+length]
+	}
+	ret := Parse(&m.Result, &m.Access, &m.Arg, box, isReq)
+	box.buffer = full
+	return ret
+}
+
+// 处理广播请求 (从站): 写请求无需响应, 其他请求丢弃
+func (m *Modbus) serveBroadcast() {
+	if !isBroadcastFunc(m.Arg.GetFuncCode()) {
+		m.Result.SetResult(ErrResultBroadcast)
+		return
+	}
+	m.Result.SetNoResponse(true)
+	m.Diag.counters.ServerNoResp++
+}
+
+// 功能码是否允许广播 (写请求、诊断及声明允许广播的自定义功能码)
+func isBroadcastFunc(funccode uint8) bool {
+	switch funccode {
+	case FuncCodeWriteCoil, FuncCodeWriteHold, FuncCodeWriteCoils, FuncCodeWriteHolds,
+		FuncCodeWriteFile, FuncCodeMaskWriteHold, FuncCodeDiag:
+		return true
+	}
+	codec := lookupFuncCode(funccode)
+	return codec != nil && codec.Broadcast
+}
+
+// 封装请求报文, 通过 transact 发送并解析响应报文 (主站)
+// 广播请求不等待响应, 发送后等待广播转换延时
+func (m *Modbus) transact(transact Transact) error {
+	req, err := m.AppendRequest(nil)
+	if err != nil {
+		return err
+	}
+	rsp, err := transact(req)
+	if err != nil {
+		return err
+	}
+	if m.Head.IsBroadcast() {
+		time.Sleep(m.Head.GetTurnaround())
+		return nil
+	}
+	return m.ParseResponse(rsp)
+}
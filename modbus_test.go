@@ -9,8 +9,11 @@ import (
 	"bytes"
 	"encoding/binary"
 	"encoding/hex"
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRTU(t *testing.T) {
@@ -28,6 +31,1034 @@ func TestTCP(t *testing.T) {
 	testParse(t, ProtocolTCP)
 }
 
+func TestAllocs(t *testing.T) {
+	testAllocs(t, ProtocolRTU, 0)
+	testAllocs(t, ProtocolAscii, 1)
+	testAllocs(t, ProtocolTCP, 0)
+}
+
+func BenchmarkRTU(b *testing.B) {
+	benchmarkCodec(b, ProtocolRTU)
+}
+
+func BenchmarkAscii(b *testing.B) {
+	benchmarkCodec(b, ProtocolAscii)
+}
+
+func BenchmarkTCP(b *testing.B) {
+	benchmarkCodec(b, ProtocolTCP)
+}
+
+func FuzzParseRequest(f *testing.F) {
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		for i := range testItems {
+			f.Add(protocol, testItems[i].packet(protocol, true))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, protocol uint8, data []byte) {
+		m := New()
+		m.Head.SetProtocol(protocol % 3)
+		m.Access.SetCheckCoil(checkDefault)
+		m.Access.SetCheckDiscrete(checkDefault)
+		m.Access.SetCheckHold(checkDefault)
+		m.Access.SetCheckInput(checkDefault)
+
+		if err := m.ParseRequest(data); err == nil && int(m.Result.GetRetLen()) > len(data) {
+			t.Fatalf("RetLen %d exceeds packet length %d.\n", m.Result.GetRetLen(), len(data))
+		}
+	})
+}
+
+func FuzzParseResponse(f *testing.F) {
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		for i := range testItems {
+			it := &testItems[i]
+			arg := strToHex(strings.Repeat("00", int(it.reglen)*2))
+			f.Add(protocol, it.devid, it.sernum, it.regaddr, it.reglen, arg, it.packet(protocol, false))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, protocol, devid uint8, sernum, regaddr, reglen uint16, arg, data []byte) {
+		m := New()
+		m.Head.SetProtocol(protocol % 3)
+		m.Head.SetDevId(devid)
+		m.Head.SetSerNum(sernum)
+		m.Arg.SetRegAddr(regaddr)
+		m.Arg.SetRegLen(reglen)
+		m.Arg.SetU8s(arg)
+
+		if err := m.ParseResponse(data); err == nil && int(m.Result.GetRetLen()) > len(data) {
+			t.Fatalf("RetLen %d exceeds packet length %d.\n", m.Result.GetRetLen(), len(data))
+		}
+	})
+}
+
+func TestExceptionError(t *testing.T) {
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		m := New()
+		m.Head.SetProtocol(protocol)
+		m.Head.SetDevId(0x01)
+		m.Arg.SetFuncCode(FuncCodeReadHold)
+		m.Arg.SetRegAddr(0x0000)
+		m.Arg.SetRegLen(0x0001)
+
+		m.Result.SetExcepCode(ExcepSlaveBusy)
+		rsp, err := m.AppendResponse(nil)
+		if err != nil {
+			t.Fatalf("%s: failed to pack exception response: %v.\n", m.Head.GetProtocolString(), err)
+		}
+
+		err = m.ParseResponse(rsp)
+		if !errors.Is(err, ErrSlaveBusy) || errors.Is(err, ErrSlaveFail) {
+			t.Fatalf("%s: expected ErrSlaveBusy, got %v.\n", m.Head.GetProtocolString(), err)
+		}
+		var excep *ExceptionError
+		if !errors.As(err, &excep) || excep.FuncCode != FuncCodeReadHold || excep.Code != ExcepSlaveBusy {
+			t.Fatalf("%s: unexpected exception error %#v.\n", m.Head.GetProtocolString(), excep)
+		}
+		if !errors.Is(err, &ExceptionError{FuncCode: FuncCodeReadHold, Code: ExcepSlaveBusy}) ||
+			errors.Is(err, &ExceptionError{FuncCode: FuncCodeReadInput, Code: ExcepSlaveBusy}) {
+			t.Fatalf("%s: function code mismatch in errors.Is.\n", m.Head.GetProtocolString())
+		}
+		if m.Result.GetExcepCode() != ExcepSlaveBusy {
+			t.Fatalf("%s: ExcepCode mismatch: got %d.\n", m.Head.GetProtocolString(), m.Result.GetExcepCode())
+		}
+		if err.Error() != "modbus exception: slave device busy (read hold)" {
+			t.Fatalf("%s: unexpected error text %q.\n", m.Head.GetProtocolString(), err.Error())
+		}
+	}
+}
+
+func TestReadWriteHolds(t *testing.T) {
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		master, slave := testPair(protocol)
+		master.Arg.SetFuncCode(FuncCodeReadWriteHolds)
+		master.Arg.SetRegAddr(0x0003)
+		master.Arg.SetRegLen(0x0006)
+		master.Arg.SetWriteRegAddr(0x000E)
+		master.Arg.SetWriteRegLen(0x0003)
+		master.Arg.SetU16s([]uint16{0x00FF, 0x00FF, 0x00FF}, binary.BigEndian)
+
+		err := testExchange(t, master, slave,
+			"17 0003 0006 000E 0003 06 00FF 00FF 00FF",
+			"17 0C 00FE 0ACD 0001 0003 000D 00FF",
+			func(s *Modbus) {
+				if s.Arg.GetRegAddr() != 0x0003 || s.Arg.GetRegLen() != 0x0006 ||
+					s.Arg.GetWriteRegAddr() != 0x000E || s.Arg.GetWriteRegLen() != 0x0003 {
+					t.Fatalf("%s: unexpected request arg %+v.\n", s.Head.GetProtocolString(), s.Arg)
+				}
+				if u16s := s.Arg.GetU16s(binary.BigEndian); len(u16s) != 3 || u16s[0] != 0x00FF {
+					t.Fatalf("%s: unexpected write values %v.\n", s.Head.GetProtocolString(), u16s)
+				}
+				s.Arg.SetU16s([]uint16{0x00FE, 0x0ACD, 0x0001, 0x0003, 0x000D, 0x00FF}, binary.BigEndian)
+			})
+		if err != nil {
+			t.Fatalf("%s: failed to parse response: %v.\n", master.Head.GetProtocolString(), err)
+		}
+		if u16s := master.Arg.GetU16s(binary.BigEndian); len(u16s) != 6 || u16s[1] != 0x0ACD {
+			t.Fatalf("%s: unexpected read values %v.\n", master.Head.GetProtocolString(), u16s)
+		}
+
+		// 写寄存器数量超出范围
+		master.Arg.SetWriteRegLen(0x007A)
+		if _, err := master.AppendRequest(nil); !errors.Is(err, ErrResultRegLen) {
+			t.Fatalf("%s: expected ErrResultRegLen, got %v.\n", master.Head.GetProtocolString(), err)
+		}
+	}
+}
+
+func TestMaskWriteHold(t *testing.T) {
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		master, slave := testPair(protocol)
+		master.Arg.SetFuncCode(FuncCodeMaskWriteHold)
+		master.Arg.SetRegAddr(0x0004)
+		master.Arg.SetMask(0x00F2, 0x0025)
+
+		var value uint16 = 0x0012
+		err := testExchange(t, master, slave,
+			"16 0004 00F2 0025",
+			"16 0004 00F2 0025",
+			func(s *Modbus) {
+				value = s.Arg.ApplyMask(value)
+			})
+		if err != nil {
+			t.Fatalf("%s: failed to parse response: %v.\n", master.Head.GetProtocolString(), err)
+		}
+		if value != 0x0017 {
+			t.Fatalf("%s: masked value mismatch: expected 0x0017, got %#04x.\n", master.Head.GetProtocolString(), value)
+		}
+
+		// 响应与请求不一致
+		rsp, _ := slave.AppendResponse(nil)
+		master.Arg.SetMask(0x00F2, 0x0026)
+		if err := master.ParseResponse(rsp); !errors.Is(err, ErrResultRegValue) {
+			t.Fatalf("%s: expected ErrResultRegValue, got %v.\n", master.Head.GetProtocolString(), err)
+		}
+	}
+}
+
+func TestCommErrorCount(t *testing.T) {
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii} {
+		master, slave := testPair(protocol)
+		name := master.Head.GetProtocolString()
+		transact := func(req []uint8) ([]uint8, error) {
+			if err := slave.ParseRequest(req); err != nil {
+				return nil, err
+			}
+			return slave.AppendResponse(nil)
+		}
+
+		// 校验码错误且 PDU 无法解析的报文 (未知功能码、字节数不一致) 同样计入通信错误
+		expect := ErrResultRtuCrc
+		frames := [][]uint8{strToHex("01 64 0000 0000"), strToHex("01 10 0000 0001 04 0001 0000")}
+		if protocol == ProtocolAscii {
+			expect = ErrResultAsciiLrc
+			frames = [][]uint8{[]uint8(":01640000\r\n"), []uint8(":01100000000104000100\r\n")}
+		}
+		for _, frame := range frames {
+			if err := slave.ParseRequest(frame); !errors.Is(err, expect) {
+				t.Fatalf("%s: %q: expected %v, got %v.\n", name, frame, expect, err)
+			}
+		}
+		if count, err := master.Diagnostic(DiagBusCommErrorCount, 0x0000, transact); err != nil || count != 2 {
+			t.Fatalf("%s: expected 2 comm errors, got %d, %v.\n", name, count, err)
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii} {
+		master, slave := testPair(protocol)
+		slave.Access.SetFilterDevID(func(devid uint8, userdata any) bool { return devid == 0x01 })
+		name := master.Head.GetProtocolString()
+
+		transact := func(req []uint8) ([]uint8, error) {
+			if err := slave.ParseRequest(req); err != nil {
+				return nil, err
+			}
+			return slave.AppendResponse(nil)
+		}
+
+		// 返回询问数据
+		if data, err := master.Diagnostic(DiagReturnQueryData, 0xA537, transact); err != nil || data != 0xA537 {
+			t.Fatalf("%s: return query data: %#04x, %v.\n", name, data, err)
+		}
+
+		// 校验错误与发往其他设备的报文
+		master.Arg.Init(FuncCodeReadHold, 0x0000, 0x0001)
+		req, _ := master.AppendRequest(nil)
+		bad := append([]uint8(nil), req...)
+		bad[len(bad)-3] ^= 0x01
+		if err := slave.ParseRequest(bad); err == nil {
+			t.Fatalf("%s: expected checksum error.\n", name)
+		}
+		master.Head.SetDevId(0x02)
+		req, _ = master.AppendRequest(nil)
+		if err := slave.ParseRequest(req); !errors.Is(err, ErrResultDevID) {
+			t.Fatalf("%s: expected ErrResultDevID, got %v.\n", name, err)
+		}
+		master.Head.SetDevId(0x01)
+
+		// 未设置从机标识时返回异常
+		if _, err := master.ReportServerId(transact); !errors.Is(err, ErrIllFuncCode) {
+			t.Fatalf("%s: expected ErrIllFuncCode, got %v.\n", name, err)
+		}
+		slave.Diag.SetServerId([]uint8{0x11, 0xFF, 'g', 'r', 'o'})
+		if id, err := master.ReportServerId(transact); err != nil || !bytes.Equal(id, []uint8{0x11, 0xFF, 'g', 'r', 'o'}) {
+			t.Fatalf("%s: report server id: %x, %v.\n", name, id, err)
+		}
+
+		slave.Diag.SetExcepStatus(0x6D)
+		if status, err := master.ReadExcepStatus(transact); err != nil || status != 0x6D {
+			t.Fatalf("%s: read exception status: %#02x, %v.\n", name, status, err)
+		}
+
+		// 计数器
+		expect := map[uint16]uint16{
+			DiagBusMessageCount:       6,
+			DiagBusCommErrorCount:     1,
+			DiagBusExcepErrorCount:    1,
+			DiagServerMessageCount:    5,
+			DiagServerNoResponseCount: 0,
+		}
+		for _, subfunc := range []uint16{DiagBusMessageCount, DiagBusCommErrorCount, DiagBusExcepErrorCount, DiagServerMessageCount, DiagServerNoResponseCount} {
+			if count, err := master.Diagnostic(subfunc, 0x0000, transact); err != nil || count != expect[subfunc] {
+				t.Fatalf("%s: sub-function %#02x: expected %d, got %d, %v.\n", name, subfunc, expect[subfunc], count, err)
+			}
+			expect[DiagBusMessageCount]++
+			expect[DiagServerMessageCount]++
+		}
+
+		// 通信事件计数不包含异常响应与获取通信事件计数/日志
+		if status, count, err := master.GetCommEventCounter(transact); err != nil || status != 0x0000 || count != 8 {
+			t.Fatalf("%s: get comm event counter: %#04x, %d, %v.\n", name, status, count, err)
+		}
+		log, err := master.GetCommEventLog(transact)
+		if err != nil || log.EventCount != 8 || log.MessageCount != 12 {
+			t.Fatalf("%s: get comm event log: %+v, %v.\n", name, log, err)
+		}
+		if expect := []uint8{EventRecv, EventSend, EventRecv, EventSend}; !bytes.Equal(log.Events[:4], expect) {
+			t.Fatalf("%s: events mismatch: %x.\n", name, log.Events)
+		}
+		if last := log.Events[len(log.Events)-5:]; !bytes.Equal(last, []uint8{EventSend | EventSendReadExcep, EventRecv, EventRecv | EventRecvCommError, EventSend, EventRecv}) {
+			t.Fatalf("%s: events mismatch: %x.\n", name, log.Events)
+		}
+
+		// 只听模式: 仅重启通信选项被处理, 且不响应
+		master.Arg.SetFuncCode(FuncCodeDiag)
+		master.Arg.SetSubFunc(DiagForceListenOnly)
+		master.Arg.SetU16s([]uint16{0x0000}, binary.BigEndian)
+		req, _ = master.AppendRequest(nil)
+		if err := slave.ParseRequest(req); !errors.Is(err, ErrResultNoResponse) {
+			t.Fatalf("%s: expected ErrResultNoResponse, got %v.\n", name, err)
+		}
+		if _, err := slave.AppendResponse(nil); !errors.Is(err, ErrResultNoResponse) {
+			t.Fatalf("%s: expected ErrResultNoResponse, got %v.\n", name, err)
+		}
+		if _, err := master.ReadExcepStatus(transact); !errors.Is(err, ErrResultNoResponse) || !slave.Diag.IsListenOnly() {
+			t.Fatalf("%s: expected ErrResultNoResponse in listen only mode, got %v.\n", name, err)
+		}
+		if _, err := master.Diagnostic(DiagRestartComm, 0xFF00, transact); !errors.Is(err, ErrResultNoResponse) || slave.Diag.IsListenOnly() {
+			t.Fatalf("%s: expected restart without response, got %v.\n", name, err)
+		}
+		if counters := slave.Diag.GetCounters(); counters != (DiagCounters{}) {
+			t.Fatalf("%s: counters not cleared: %+v.\n", name, counters)
+		}
+		if events := slave.Diag.GetEvents(); !bytes.Equal(events, []uint8{EventRestart}) {
+			t.Fatalf("%s: events not cleared: %x.\n", name, events)
+		}
+
+		// 非法的子功能码与数据
+		if _, err := master.Diagnostic(0x0013, 0x0000, transact); !errors.Is(err, ErrIllFuncCode) {
+			t.Fatalf("%s: expected ErrIllFuncCode, got %v.\n", name, err)
+		}
+		if _, err := master.Diagnostic(DiagRestartComm, 0x0001, transact); !errors.Is(err, ErrIllDataValue) {
+			t.Fatalf("%s: expected ErrIllDataValue, got %v.\n", name, err)
+		}
+
+		// 返回询问数据 (N x 2 字节回送)
+		echo := []uint16{0x0102, 0x0304, 0x0506}
+		master.Arg.SetFuncCode(FuncCodeDiag)
+		master.Arg.SetSubFunc(DiagReturnQueryData)
+		master.Arg.SetU16s(echo, binary.BigEndian)
+		req, _ = master.AppendRequest(nil)
+		if protocol == ProtocolRTU {
+			if n := RTUFrameLen(req, true); n != -1 {
+				t.Fatalf("%s: expected unpredictable echo frame length, got %d.\n", name, n)
+			}
+		}
+		if err := master.transact(transact); err != nil {
+			t.Fatalf("%s: multi-word echo: %v.\n", name, err)
+		}
+		if u16s := master.Arg.GetU16s(binary.BigEndian); !reflect.DeepEqual(u16s, echo) {
+			t.Fatalf("%s: multi-word echo mismatch: %04x.\n", name, u16s)
+		}
+		if slave.Arg.GetSubFunc() != DiagReturnQueryData || len(slave.Arg.GetU8s()) != 6 {
+			t.Fatalf("%s: slave parsed echo %x.\n", name, slave.Arg.GetU8s())
+		}
+	}
+
+	// 修改 Ascii 输入结束符
+	master, slave := testPair(ProtocolAscii)
+	master.Arg.SetFuncCode(FuncCodeDiag)
+	master.Arg.SetSubFunc(DiagChangeAsciiDelimiter)
+	master.Arg.SetU16s([]uint16{0x2100}, binary.BigEndian)
+	if err := testExchange(t, master, slave, "08 0003 2100", "", nil); !errors.Is(err, ErrResultAsciiEnd) {
+		t.Fatalf("Expected ErrResultAsciiEnd, got %v.\n", err)
+	}
+	if slave.Head.GetAsciiEnd() != '!' {
+		t.Fatalf("Delimiter not changed: %q.\n", slave.Head.GetAsciiEnd())
+	}
+
+	// Modbus TCP 没有串行链路: 串行链路专用的功能码回复非法功能码异常, 不进入只听模式
+	master, slave = testPair(ProtocolTCP)
+	slave.Diag.SetServerId([]uint8{0x11, 0xFF})
+	master.Arg.SetFuncCode(FuncCodeDiag)
+	master.Arg.SetSubFunc(DiagForceListenOnly)
+	master.Arg.SetU16s([]uint16{0x0000}, binary.BigEndian)
+	if err := testExchange(t, master, slave, "08 0004 0000", "88 01", nil); !errors.Is(err, ErrIllFuncCode) {
+		t.Fatalf("Expected ErrIllFuncCode for force listen only, got %v.\n", err)
+	}
+	if slave.Diag.IsListenOnly() {
+		t.Fatalf("Modbus TCP entered listen only mode.\n")
+	}
+	master.Arg.SetFuncCode(FuncCodeReportServerId)
+	if err := testExchange(t, master, slave, "11", "91 01", nil); !errors.Is(err, ErrIllFuncCode) {
+		t.Fatalf("Expected ErrIllFuncCode for report server id, got %v.\n", err)
+	}
+	master.Arg.Init(FuncCodeReadHold, 0x0000, 0x0001)
+	err := testExchange(t, master, slave, "03 0000 0001", "03 02 1234", func(s *Modbus) {
+		s.Arg.SetU16s([]uint16{0x1234}, binary.BigEndian)
+	})
+	if err != nil {
+		t.Fatalf("Failed to read after force listen only: %v.\n", err)
+	}
+}
+
+func TestFileRecord(t *testing.T) {
+	files := map[uint16][]uint16{
+		0x0003: make([]uint16, 0x10),
+		0x0004: make([]uint16, 0x10),
+	}
+	files[0x0004][0x0001], files[0x0004][0x0002] = 0x0DFE, 0x0020
+	files[0x0003][0x0009], files[0x0003][0x000A] = 0x33CD, 0x0040
+	writes := 0
+	fileStore := func(file, record uint16, data []uint8, isRead bool, userdata any) bool {
+		if !isRead {
+			writes++
+		}
+		regs, ok := files[file]
+		if !ok || int(record)+len(data)/2 > len(regs) {
+			return false
+		}
+		for i := 0; i < len(data)/2; i++ {
+			if isRead {
+				binary.BigEndian.PutUint16(data[i*2:], regs[int(record)+i])
+			} else {
+				regs[int(record)+i] = binary.BigEndian.Uint16(data[i*2:])
+			}
+		}
+		return true
+	}
+
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		master, slave := testPair(protocol)
+		slave.Access.SetFileStore(fileStore)
+
+		// 读文件记录
+		master.Arg.SetFuncCode(FuncCodeReadFile)
+		master.Arg.SetFileRecords([]FileRecord{
+			{File: 0x0004, Record: 0x0001, Length: 0x0002},
+			{File: 0x0003, Record: 0x0009, Length: 0x0002},
+		})
+		err := testExchange(t, master, slave,
+			"14 0E 06 0004 0001 0002 06 0003 0009 0002",
+			"14 0C 05 06 0DFE 0020 05 06 33CD 0040",
+			nil)
+		if err != nil {
+			t.Fatalf("%s: failed to parse response: %v.\n", master.Head.GetProtocolString(), err)
+		}
+		if got := master.Arg.GetFileRecords(); !bytes.Equal(got[0].Data, strToHex("0DFE 0020")) || !bytes.Equal(got[1].Data, strToHex("33CD 0040")) {
+			t.Fatalf("%s: record data mismatch: %v.\n", master.Head.GetProtocolString(), got)
+		}
+
+		// 写文件记录
+		master.Arg.SetFuncCode(FuncCodeWriteFile)
+		master.Arg.SetFileRecords([]FileRecord{
+			{File: 0x0004, Record: 0x0007, Length: 0x0003, Data: strToHex("06AF 04BE 100D")},
+		})
+		err = testExchange(t, master, slave,
+			"15 0D 06 0004 0007 0003 06AF 04BE 100D",
+			"15 0D 06 0004 0007 0003 06AF 04BE 100D",
+			nil)
+		if err != nil {
+			t.Fatalf("%s: failed to parse response: %v.\n", master.Head.GetProtocolString(), err)
+		}
+		if regs := files[0x0004][0x0007:0x000A]; regs[0] != 0x06AF || regs[1] != 0x04BE || regs[2] != 0x100D {
+			t.Fatalf("%s: written records mismatch: %04X.\n", master.Head.GetProtocolString(), regs)
+		}
+
+		// 文件不存在
+		master.Arg.SetFuncCode(FuncCodeReadFile)
+		master.Arg.SetFileRecords([]FileRecord{{File: 0x0005, Record: 0x0000, Length: 0x0001}})
+		if err := testExchange(t, master, slave, "", "94 02", nil); !errors.Is(err, ErrIllDataAddr) {
+			t.Fatalf("%s: expected ErrIllDataAddr, got %v.\n", master.Head.GetProtocolString(), err)
+		}
+
+		// 校验码错误的写请求不写入记录数据
+		if protocol == ProtocolTCP {
+			continue
+		}
+		master.Arg.SetFuncCode(FuncCodeWriteFile)
+		master.Arg.SetFileRecords([]FileRecord{
+			{File: 0x0004, Record: 0x0007, Length: 0x0003, Data: strToHex("1111 2222 3330")},
+		})
+		req, _ := master.AppendRequest(nil)
+		expect := ErrResultRtuCrc
+		if protocol == ProtocolRTU {
+			req[len(req)-3] ^= 0x01
+		} else {
+			req[len(req)-5] ^= 0x01
+			expect = ErrResultAsciiLrc
+		}
+		n := writes
+		if err := slave.ParseRequest(req); !errors.Is(err, expect) {
+			t.Fatalf("%s: expected %v, got %v.\n", master.Head.GetProtocolString(), expect, err)
+		}
+		if writes != n || files[0x0004][0x0009] != 0x100D {
+			t.Fatalf("%s: corrupted records written: %d.\n", master.Head.GetProtocolString(), writes-n)
+		}
+	}
+
+	master, _ := testPair(ProtocolTCP)
+
+	// 记录号超出范围
+	master.Arg.SetFuncCode(FuncCodeReadFile)
+	master.Arg.SetFileRecords([]FileRecord{{File: 0x0001, Record: 0x270F, Length: 0x0002}})
+	if _, err := master.AppendRequest(nil); !errors.Is(err, ErrResultRegAddr) {
+		t.Fatalf("Expected ErrResultRegAddr, got %v.\n", err)
+	}
+
+	// 响应超出 PDU 长度
+	master.Arg.SetFileRecords([]FileRecord{{File: 0x0001, Record: 0x0000, Length: 0x007D}, {File: 0x0001, Record: 0x0000, Length: 0x0001}})
+	if _, err := master.AppendRequest(nil); !errors.Is(err, ErrResultLength) {
+		t.Fatalf("Expected ErrResultLength, got %v.\n", err)
+	}
+
+	// 请求超出 PDU 长度
+	master.Arg.SetFuncCode(FuncCodeWriteFile)
+	master.Arg.SetFileRecords([]FileRecord{{File: 0x0001, Record: 0x0000, Length: 0x007B, Data: make([]uint8, 0xF6)}})
+	if _, err := master.AppendRequest(nil); !errors.Is(err, ErrResultLength) {
+		t.Fatalf("Expected ErrResultLength, got %v.\n", err)
+	}
+	master.Arg.SetFileRecords([]FileRecord{{File: 0x0001, Record: 0x0000, Length: 0x007A, Data: make([]uint8, 0xF4)}})
+	if _, err := master.AppendRequest(nil); err != nil {
+		t.Fatalf("Failed to pack request: %v.\n", err)
+	}
+}
+
+func TestReadFifo(t *testing.T) {
+	fifo := map[uint16][]uint16{
+		0x04DE: {0x01B8, 0x1284},
+		0x04DF: make([]uint16, 32),
+	}
+	readFifo := func(addr uint16, userdata any) ([]uint16, bool) {
+		values, ok := fifo[addr]
+		return values, ok
+	}
+
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		master, slave := testPair(protocol)
+		slave.Access.SetReadFifo(readFifo)
+		master.Arg.SetFuncCode(FuncCodeReadFifo)
+		master.Arg.SetRegAddr(0x04DE)
+
+		err := testExchange(t, master, slave, "18 04DE", "18 0006 0002 01B8 1284", nil)
+		if err != nil {
+			t.Fatalf("%s: failed to parse response: %v.\n", master.Head.GetProtocolString(), err)
+		}
+		if got := master.Arg.GetU16s(binary.BigEndian); len(got) != 2 || got[0] != 0x01B8 || got[1] != 0x1284 {
+			t.Fatalf("%s: FIFO values mismatch: %v.\n", master.Head.GetProtocolString(), got)
+		}
+
+		// 队列超过 31 个寄存器
+		master.Arg.SetFuncCode(FuncCodeReadFifo)
+		master.Arg.SetRegAddr(0x04DF)
+		if err := testExchange(t, master, slave, "18 04DF", "98 03", nil); !errors.Is(err, ErrIllDataValue) {
+			t.Fatalf("%s: expected ErrIllDataValue, got %v.\n", master.Head.GetProtocolString(), err)
+		}
+
+		// 队列不存在
+		master.Arg.SetFuncCode(FuncCodeReadFifo)
+		master.Arg.SetRegAddr(0x0000)
+		if err := testExchange(t, master, slave, "18 0000", "98 02", nil); !errors.Is(err, ErrIllDataAddr) {
+			t.Fatalf("%s: expected ErrIllDataAddr, got %v.\n", master.Head.GetProtocolString(), err)
+		}
+	}
+
+	// 字节数与队列数量不一致
+	master, _ := testPair(ProtocolTCP)
+	master.Arg.SetFuncCode(FuncCodeReadFifo)
+	for _, pdu := range []string{"18 0008 0002 01B8 1284", "18 0042 0020" + strings.Repeat(" 0000", 32)} {
+		raw := strToHex(pdu)
+		rsp := binary.BigEndian.AppendUint16(strToHex("0001 0000"), uint16(len(raw)+1))
+		rsp = append(append(rsp, 0x01), raw...)
+		if err := master.ParseResponse(rsp); !errors.Is(err, ErrResultLength) {
+			t.Fatalf("%s: expected ErrResultLength, got %v.\n", pdu, err)
+		}
+	}
+}
+
+func TestReadDevIdent(t *testing.T) {
+	objects := map[uint8]string{
+		ObjVendorName:         "Company identification",
+		ObjProductCode:        "Product code XX",
+		ObjMajorMinorRevision: "V2.11",
+	}
+	devIdent := func(objid uint8, userdata any) ([]uint8, bool) {
+		value, ok := objects[objid]
+		return []uint8(value), ok
+	}
+
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		master, slave := testPair(protocol)
+		slave.Access.SetDevIdent(devIdent)
+		master.Arg.SetFuncCode(FuncCodeMei)
+		master.Arg.SetDevIdent(DevIdBasic, ObjVendorName)
+
+		err := testExchange(t, master, slave,
+			"2B 0E 01 00",
+			"2B 0E 01 81 00 00 03"+
+				" 00 16 436F6D70616E79206964656E74696669636174696F6E"+
+				" 01 0F 50726F6475637420636F6465205858"+
+				" 02 05 56322E3131",
+			nil)
+		if err != nil {
+			t.Fatalf("%s: failed to parse response: %v.\n", master.Head.GetProtocolString(), err)
+		}
+		if got := master.Arg.GetObjects(); len(got) != 3 || string(got[2].Value) != "V2.11" {
+			t.Fatalf("%s: objects mismatch: %v.\n", master.Head.GetProtocolString(), got)
+		}
+
+		// 单独访问不存在的对象
+		master.Arg.SetDevIdent(DevIdSpecific, ObjModelName)
+		err = testExchange(t, master, slave, "2B 0E 04 05", "AB 02", nil)
+		if !errors.Is(err, ErrIllDataAddr) {
+			t.Fatalf("%s: expected ErrIllDataAddr, got %v.\n", master.Head.GetProtocolString(), err)
+		}
+
+		// 非法的读设备标识码
+		master.Arg.SetFuncCode(FuncCodeMei)
+		master.Arg.SetDevIdent(0x05, 0)
+		if _, err := master.AppendRequest(nil); !errors.Is(err, ErrResultRegValue) {
+			t.Fatalf("%s: expected ErrResultRegValue, got %v.\n", master.Head.GetProtocolString(), err)
+		}
+	}
+}
+
+func TestReadDevIdentPaging(t *testing.T) {
+	// 扩展对象总长度超过单个 PDU, 需要分页读取
+	devIdent := func(objid uint8, userdata any) ([]uint8, bool) {
+		if objid > ObjMajorMinorRevision && objid < 0x80 || objid > 0x83 {
+			return nil, false
+		}
+		return bytes.Repeat([]uint8{'A' + objid%26}, 100), true
+	}
+
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		master, slave := testPair(protocol)
+		slave.Access.SetDevIdent(devIdent)
+
+		requests := 0
+		transact := func(req []uint8) ([]uint8, error) {
+			requests++
+			if err := slave.ParseRequest(req); err != nil {
+				return nil, err
+			}
+			if slave.Arg.GetConformity() != 0x83 {
+				t.Fatalf("%s: conformity mismatch: %#02x.\n", slave.Head.GetProtocolString(), slave.Arg.GetConformity())
+			}
+			rsp, err := slave.AppendResponse(nil)
+			if protocol == ProtocolRTU && RTUFrameLen(rsp, false) != len(rsp) {
+				t.Fatalf("RTUFrameLen mismatch: Expected = %d, Actual = %d.\n", len(rsp), RTUFrameLen(rsp, false))
+			}
+			return rsp, err
+		}
+
+		got, err := master.ReadDevIdent(DevIdExtended, 0, transact)
+		if err != nil {
+			t.Fatalf("%s: failed to read device identification: %v.\n", master.Head.GetProtocolString(), err)
+		}
+		if len(got) != 7 || requests != 4 {
+			t.Fatalf("%s: expected 7 objects in 4 requests, got %d in %d.\n", master.Head.GetProtocolString(), len(got), requests)
+		}
+		for i, obj := range got {
+			if expect := []uint8{0, 1, 2, 0x80, 0x81, 0x82, 0x83}[i]; obj.Id != expect || len(obj.Value) != 100 || obj.Value[0] != 'A'+expect%26 {
+				t.Fatalf("%s: [%d] object mismatch: %#02x %q.\n", master.Head.GetProtocolString(), i, obj.Id, obj.Value)
+			}
+		}
+	}
+}
+
+func TestRegisterFuncCode(t *testing.T) {
+	const code = 0x41 // 用户自定义功能码 65
+
+	// 请求: 地址(2), 响应: 字节数(1) + 数据(n)
+	err := RegisterFuncCode(code, FuncCodec{
+		Name: "vendor read",
+		PackRequest: func(dst, data []uint8) ([]uint8, error) {
+			return append(dst, data[0:2]...), nil
+		},
+		ParseRequest: func(pdu []uint8) (int, []uint8, error) {
+			if len(pdu) < 2 {
+				return 0, nil, ErrResultTooShort
+			}
+			if binary.BigEndian.Uint16(pdu) == 0xFFFF {
+				return 2, nil, ErrIllDataAddr
+			}
+			return 2, pdu[0:2], nil
+		},
+		PackResponse: func(dst, data []uint8) ([]uint8, error) {
+			return append(append(dst, uint8(len(data))), data...), nil
+		},
+		ParseResponse: func(pdu []uint8) (int, []uint8, error) {
+			if len(pdu) < 1 || len(pdu) < 1+int(pdu[0]) {
+				return 0, nil, ErrResultTooShort
+			}
+			return 1 + int(pdu[0]), pdu[1 : 1+pdu[0]], nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register function code: %v.\n", err)
+	}
+	if name := FuncCodeToString(code); name != "vendor read" {
+		t.Fatalf("FuncCodeToString mismatch: %q.\n", name)
+	}
+
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		master, slave := testPair(protocol)
+		master.Arg.SetFuncCode(code)
+		master.Arg.SetU8s(strToHex("0010"))
+
+		err := testExchange(t, master, slave, "41 0010", "41 04 DEADBEEF", func(s *Modbus) {
+			if !bytes.Equal(s.Arg.GetU8s(), strToHex("0010")) {
+				t.Fatalf("%s: request data mismatch: %x.\n", s.Head.GetProtocolString(), s.Arg.GetU8s())
+			}
+			s.Arg.SetU8s(strToHex("DEADBEEF"))
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to parse response: %v.\n", master.Head.GetProtocolString(), err)
+		}
+		if !bytes.Equal(master.Arg.GetU8s(), strToHex("DEADBEEF")) {
+			t.Fatalf("%s: response data mismatch: %x.\n", master.Head.GetProtocolString(), master.Arg.GetU8s())
+		}
+
+		// 编解码器返回异常
+		master.Arg.SetFuncCode(code)
+		master.Arg.SetU8s(strToHex("FFFF"))
+		err = testExchange(t, master, slave, "41 FFFF", "C1 02", nil)
+		if !errors.Is(err, &ExceptionError{FuncCode: code, Code: ExcepIllDataAddr}) {
+			t.Fatalf("%s: expected ErrIllDataAddr, got %v.\n", master.Head.GetProtocolString(), err)
+		}
+	}
+
+	// 自定义功能码默认不允许广播 (如读请求)
+	master, slave := testPair(ProtocolRTU)
+	master.Head.SetDevId(DevIdBroadcast)
+	master.Arg.SetFuncCode(code)
+	master.Arg.SetU8s(strToHex("0010"))
+	if _, err := master.AppendRequest(nil); !errors.Is(err, ErrResultBroadcast) {
+		t.Fatalf("Expected ErrResultBroadcast, got %v.\n", err)
+	}
+	req := strToHex("00 41 0010")
+	req = binary.LittleEndian.AppendUint16(req, CRC16(req))
+	if err := slave.ParseRequest(req); !errors.Is(err, ErrResultBroadcast) {
+		t.Fatalf("Expected ErrResultBroadcast from slave, got %v.\n", err)
+	}
+
+	// 声明允许广播后从站执行但不响应
+	codec := *lookupFuncCode(code)
+	codec.Broadcast = true
+	if err := RegisterFuncCode(code, codec); err != nil {
+		t.Fatalf("Failed to register function code: %v.\n", err)
+	}
+	if _, err := master.AppendRequest(nil); err != nil {
+		t.Fatalf("Failed to pack broadcast request: %v.\n", err)
+	}
+	if err := slave.ParseRequest(req); err != nil {
+		t.Fatalf("Failed to parse broadcast request: %v.\n", err)
+	}
+	if _, err := slave.AppendResponse(nil); !errors.Is(err, ErrResultNoResponse) {
+		t.Fatalf("Expected ErrResultNoResponse, got %v.\n", err)
+	}
+
+	// 不允许注册内置功能码与无效功能码
+	for _, c := range []uint8{0x00, FuncCodeReadHold, FuncCodeMei, 0xC1} {
+		if err := RegisterFuncCode(c, FuncCodec{}); !errors.Is(err, ErrResultFuncCode) {
+			t.Fatalf("RegisterFuncCode(%#02x): expected ErrResultFuncCode, got %v.\n", c, err)
+		}
+	}
+}
+
+func TestRawPdu(t *testing.T) {
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		master, slave := testPair(protocol)
+		name := master.Head.GetProtocolString()
+
+		// 原始 PDU 与功能码封装的报文一致
+		master.Arg.Init(FuncCodeReadHold, 0x006B, 0x0003)
+		expect, _ := master.AppendRequest(nil)
+		master.Arg.SetRawMode(true)
+		master.Arg.SetRawPdu(strToHex("03 006B 0003"))
+		if req, _ := master.AppendRequest(nil); !bytes.Equal(req, expect) {
+			t.Fatalf("%s: request mismatch: Expected = %x, Actual = %x.\n", name, expect, req)
+		}
+		err := testExchange(t, master, slave, "03 006B 0003", "03 06 022B 0000 0064", func(s *Modbus) {
+			s.Arg.SetU16s([]uint16{0x022B, 0x0000, 0x0064}, binary.BigEndian)
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to parse response: %v.\n", name, err)
+		}
+		if pdu := master.Arg.GetRawPdu(); !bytes.Equal(pdu, strToHex("03 06 022B 0000 0064")) {
+			t.Fatalf("%s: response PDU mismatch: %x.\n", name, pdu)
+		}
+
+		// 异常响应
+		slave.Arg.SetRawMode(true)
+		master.Arg.SetRawPdu(strToHex("64 0102"))
+		err = testExchange(t, master, slave, "64 0102", "E4 01", func(s *Modbus) {
+			if !bytes.Equal(s.Arg.GetRawPdu(), strToHex("64 0102")) {
+				t.Fatalf("%s: request PDU mismatch: %x.\n", name, s.Arg.GetRawPdu())
+			}
+			s.Arg.SetRawPdu([]uint8{0xE4, ExcepIllFuncCode})
+		})
+		if !errors.Is(err, &ExceptionError{FuncCode: 0x64, Code: ExcepIllFuncCode}) {
+			t.Fatalf("%s: expected ErrIllFuncCode, got %v.\n", name, err)
+		}
+
+		// 校验错误
+		master.Arg.SetRawPdu(strToHex("64 0102"))
+		req, _ := master.AppendRequest(nil)
+		if protocol != ProtocolTCP {
+			req[len(req)-3] ^= 0x01
+			if err := slave.ParseRequest(req); err == nil {
+				t.Fatalf("%s: expected checksum error.\n", name)
+			}
+		}
+
+		// 空 PDU
+		master.Arg.SetRawPdu(nil)
+		if _, err := master.AppendRequest(nil); !errors.Is(err, ErrResultLength) {
+			t.Fatalf("%s: expected ErrResultLength, got %v.\n", name, err)
+		}
+	}
+}
+
+func TestAccessRole(t *testing.T) {
+	master, slave := testPair(ProtocolTCP)
+
+	// 角色限制功能码与地址 (Viewer 只能读取保持寄存器 0x0000~0x0009)
+	slave.Access.SetCheckRole(func(role string, funccode uint8, userdata any) bool {
+		return role != ""
+	})
+	slave.Access.SetCheckRoleAddr(func(role string, table Table, regaddr, reglen uint16, isRead bool, userdata any) bool {
+		return role == "Operator" || table == TableHold && isRead && uint32(regaddr)+uint32(reglen) <= 0x000A
+	})
+
+	tests := []struct {
+		role     string
+		funccode uint8
+		regaddr  uint16
+		expect   error
+	}{
+		{"Operator", FuncCodeWriteHold, 0x0064, nil},
+		{"Viewer", FuncCodeReadHold, 0x0009, nil},
+		{"Viewer", FuncCodeReadHold, 0x0064, ErrIllDataAddr},
+		{"Viewer", FuncCodeWriteHold, 0x0000, ErrIllDataAddr},
+		{"Viewer", FuncCodeReadCoil, 0x0000, ErrIllDataAddr},
+		{"", FuncCodeReadHold, 0x0000, ErrIllFuncCode},
+	}
+	for _, tt := range tests {
+		slave.Access.SetRole(tt.role)
+		master.Arg.Init(tt.funccode, tt.regaddr, 0x0001)
+		if tt.funccode == FuncCodeWriteHold {
+			master.Arg.SetU16s([]uint16{0x0001}, binary.BigEndian)
+		}
+		err := testExchange(t, master, slave, "", "", func(s *Modbus) {
+			if s.Arg.GetFuncCode() == FuncCodeReadHold {
+				s.Arg.SetU16s(make([]uint16, s.Arg.GetRegLen()), binary.BigEndian)
+			}
+		})
+		if !errors.Is(err, tt.expect) {
+			t.Fatalf("%q %#02x %#04x: expected %v, got %v.\n", tt.role, tt.funccode, tt.regaddr, tt.expect, err)
+		}
+	}
+}
+
+func TestOverTcp(t *testing.T) {
+	for _, pair := range [][2]uint8{{ProtocolRtuOverTcp, ProtocolRTU}, {ProtocolAsciiOverTcp, ProtocolAscii}} {
+		protocol, serial := pair[0], pair[1]
+		master, slave := testPair(protocol)
+		name := master.Head.GetProtocolString()
+
+		// 与串行链路的帧格式相同
+		item := &testItems[0]
+		testSetup(master, protocol, item)
+		if req, _ := master.AppendRequest(nil); !bytes.Equal(req, item.packet(serial, true)) {
+			t.Fatalf("%s: request mismatch: %x.\n", name, req)
+		}
+
+		master.Arg.Init(FuncCodeReadHold, 0x0000, 0x0002)
+		err := testExchange(t, master, slave, "03 0000 0002", "03 04 0001 0002", func(s *Modbus) {
+			s.Arg.SetU16s([]uint16{0x0001, 0x0002}, binary.BigEndian)
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to parse response: %v.\n", name, err)
+		}
+		stale, _ := slave.AppendResponse(nil)
+
+		// 之前请求的响应 (功能码不一致) 被丢弃, 不影响随后正确响应的解析
+		master.Arg.Init(FuncCodeReadInput, 0x0000, 0x0002)
+		req, _ := master.AppendRequest(nil)
+		if err := master.ParseResponse(stale); !errors.Is(err, ErrResultFuncCode) {
+			t.Fatalf("%s: expected ErrResultFuncCode, got %v.\n", name, err)
+		}
+		if err := slave.ParseRequest(req); err != nil {
+			t.Fatalf("%s: failed to parse request: %v.\n", name, err)
+		}
+		slave.Arg.SetU16s([]uint16{0x0003, 0x0004}, binary.BigEndian)
+		rsp, _ := slave.AppendResponse(nil)
+		if err := master.ParseResponse(rsp); err != nil {
+			t.Fatalf("%s: failed to parse response: %v.\n", name, err)
+		}
+		if master.Arg.GetFuncCode() != FuncCodeReadInput || !bytes.Equal(master.Arg.GetU8s(), strToHex("0003 0004")) {
+			t.Fatalf("%s: response mismatch: %02x %x.\n", name, master.Arg.GetFuncCode(), master.Arg.GetU8s())
+		}
+
+		// 对应功能码的异常响应
+		slave.Access.SetCheckInput(func(regaddr, reglen uint16, isRead bool, userdata any) bool { return false })
+		master.Arg.Init(FuncCodeReadInput, 0x0000, 0x0002)
+		if err := testExchange(t, master, slave, "04 0000 0002", "84 02", nil); !errors.Is(err, ErrIllDataAddr) {
+			t.Fatalf("%s: expected ErrIllDataAddr, got %v.\n", name, err)
+		}
+	}
+
+	if s := ProtocolToString(ProtocolRtuOverTcp); s != "modbus RTU over TCP" {
+		t.Fatalf("ProtocolToString mismatch: %q.\n", s)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii} {
+		master, slave := testPair(protocol)
+		name := master.Head.GetProtocolString()
+		master.Head.SetDevId(DevIdBroadcast)
+		master.Head.SetTurnaround(time.Millisecond)
+		slave.Access.SetFilterDevID(func(devid uint8, userdata any) bool { return devid == 0x01 })
+
+		// 广播读请求: 主站拒绝封装, 从站丢弃
+		master.Arg.Init(FuncCodeReadHold, 0x0000, 0x0001)
+		if _, err := master.AppendRequest(nil); !errors.Is(err, ErrResultBroadcast) {
+			t.Fatalf("%s: expected ErrResultBroadcast, got %v.\n", name, err)
+		}
+		master.Head.SetDevId(0x01)
+		req, _ := master.AppendRequest(nil)
+		master.Head.SetDevId(DevIdBroadcast)
+		if protocol == ProtocolRTU {
+			req[0] = DevIdBroadcast
+			binary.LittleEndian.PutUint16(req[len(req)-2:], CRC16(req[:len(req)-2]))
+		} else {
+			raw := strToHex(string(req[1 : len(req)-2]))
+			raw[0] = DevIdBroadcast
+			raw[len(raw)-1] = LRCCalcul(raw[:len(raw)-1])
+			req = append(append([]uint8{':'}, strings.ToUpper(hex.EncodeToString(raw))...), '\r', '\n')
+		}
+		if err := slave.ParseRequest(req); !errors.Is(err, ErrResultBroadcast) {
+			t.Fatalf("%s: expected ErrResultBroadcast, got %v.\n", name, err)
+		}
+
+		// 广播写请求: 从站执行但不响应, 主站不等待响应
+		executed := false
+		master.Arg.Init(FuncCodeWriteHold, 0x0001, 0x0001)
+		master.Arg.SetU16s([]uint16{0x1234}, binary.BigEndian)
+		err := master.transact(func(req []uint8) ([]uint8, error) {
+			if err := slave.ParseRequest(req); err != nil {
+				t.Fatalf("%s: failed to parse broadcast request: %v.\n", name, err)
+			}
+			if slave.Result.GetExcepCode() == ExcepNormal {
+				executed = slave.Arg.GetU16(0, binary.BigEndian) == 0x1234
+			}
+			if _, err := slave.AppendResponse(nil); !errors.Is(err, ErrResultNoResponse) {
+				t.Fatalf("%s: expected ErrResultNoResponse, got %v.\n", name, err)
+			}
+			return nil, nil
+		})
+		if err != nil || !executed {
+			t.Fatalf("%s: broadcast write failed: %v, executed = %v.\n", name, err, executed)
+		}
+
+		counters := slave.Diag.GetCounters()
+		if counters.ServerMessage != 2 || counters.ServerNoResp != 1 {
+			t.Fatalf("%s: counters mismatch: %+v.\n", name, counters)
+		}
+		if events := slave.Diag.GetEvents(); len(events) == 0 || events[0] != EventRecv|EventRecvBroadcast {
+			t.Fatalf("%s: events mismatch: %x.\n", name, events)
+		}
+	}
+
+	// Modbus TCP 没有广播
+	master := New()
+	master.Head.InitTcp(DevIdBroadcast, 0x0001)
+	master.Arg.Init(FuncCodeReadHold, 0x0000, 0x0001)
+	if _, err := master.AppendRequest(nil); err != nil {
+		t.Fatalf("TCP: unexpected error: %v.\n", err)
+	}
+}
+
+func TestRegWidths(t *testing.T) {
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		master, slave := testPair(protocol)
+		name := master.Head.GetProtocolString()
+		for _, m := range []*Modbus{master, slave} {
+			if err := m.Arg.SetRegWidths(EnronRegWidths); err != nil {
+				t.Fatalf("%s: failed to set register widths: %v.\n", name, err)
+			}
+		}
+
+		// 32 位寄存器: 每个寄存器 4 字节
+		master.Arg.Init(FuncCodeReadHold, 7000, 0x0002)
+		err := testExchange(t, master, slave, "03 1B58 0002", "03 08 3F800000 40000000", func(s *Modbus) {
+			s.Arg.SetFloat32s([]float32{1, 2}, binary.BigEndian)
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to read 32-bit registers: %v.\n", name, err)
+		}
+		if got := master.Arg.GetFloat32s(binary.BigEndian); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+			t.Fatalf("%s: values mismatch: %v.\n", name, got)
+		}
+
+		master.Arg.Init(FuncCodeWriteHolds, 5000, 0x0001)
+		master.Arg.SetU8s(strToHex("00010002"))
+		if err := testExchange(t, master, slave, "10 1388 0001 04 00010002", "10 1388 0001", nil); err != nil {
+			t.Fatalf("%s: failed to write 32-bit registers: %v.\n", name, err)
+		}
+
+		// 范围之外仍为 16 位寄存器
+		master.Arg.Init(FuncCodeReadHold, 0x0000, 0x0001)
+		err = testExchange(t, master, slave, "03 0000 0001", "03 02 1234", func(s *Modbus) {
+			s.Arg.SetU16s([]uint16{0x1234}, binary.BigEndian)
+		})
+		if err != nil {
+			t.Fatalf("%s: failed to read 16-bit registers: %v.\n", name, err)
+		}
+
+		// 数量上限按字节数计算, 不允许跨越不同宽度的范围
+		for _, tt := range []struct {
+			funccode uint8
+			regaddr  uint16
+			reglen   uint16
+		}{
+			{FuncCodeReadHold, 7000, 63},
+			{FuncCodeWriteHolds, 7000, 62},
+			{FuncCodeReadHold, 4999, 2},
+			{FuncCodeReadHold, 5999, 2},
+		} {
+			master.Arg.Init(tt.funccode, tt.regaddr, tt.reglen)
+			master.Arg.SetU8s(make([]uint8, 256))
+			if _, err := master.AppendRequest(nil); !errors.Is(err, ErrResultRegLen) {
+				t.Fatalf("%s: %d+%d: expected ErrResultRegLen, got %v.\n", name, tt.regaddr, tt.reglen, err)
+			}
+		}
+		master.Arg.Init(FuncCodeReadHold, 7000, 62)
+		if _, err := master.AppendRequest(nil); err != nil {
+			t.Fatalf("%s: failed to pack 62 registers: %v.\n", name, err)
+		}
+
+		// 从站未配置寄存器宽度时, 字节数不一致的写请求回复异常
+		slave.Arg.SetRegWidths(nil)
+		master.Arg.Init(FuncCodeWriteHolds, 5000, 0x0001)
+		master.Arg.SetU8s(strToHex("00010002"))
+		if err := testExchange(t, master, slave, "", "90 03", nil); !errors.Is(err, ErrIllDataValue) {
+			t.Fatalf("%s: expected ErrIllDataValue, got %v.\n", name, err)
+		}
+	}
+
+	var arg groArg
+	if err := arg.SetRegWidths([]RegWidth{{Start: 0, End: 9, Width: 3}}); !errors.Is(err, ErrResultRegWidth) {
+		t.Fatalf("Expected ErrResultRegWidth for invalid width, got %v.\n", err)
+	}
+	if err := arg.SetRegWidths([]RegWidth{{Start: 0, End: 9, Width: 4}, {Start: 9, End: 10, Width: 4}}); !errors.Is(err, ErrResultRegWidth) {
+		t.Fatalf("Expected ErrResultRegWidth for overlapping ranges, got %v.\n", err)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	for _, protocol := range []uint8{ProtocolRTU, ProtocolAscii, ProtocolTCP} {
+		testAppend(t, protocol)
+	}
+}
+
 type testItem struct {
 	funccode uint8    // 功能码
 	devid    uint8    // 设备标识
@@ -67,6 +1098,11 @@ func (t *testItem) packet(protocol uint8, isReq bool) []uint8 {
 	return nil
 }
 
+// 功能码名称 (用于测试名称)
+func (t *testItem) funccode2str() string {
+	return strings.ReplaceAll(FuncCodeToString(t.funccode), " ", "_")
+}
+
 var testItems = []testItem{
 	{
 		funccode: FuncCodeReadCoil,
@@ -633,3 +1669,212 @@ func testParse(t *testing.T, protocol uint8) {
 		}
 	}
 }
+
+// testAppend 测试追加封装功能
+func testAppend(t *testing.T, protocol uint8) {
+	m := New()
+	m.Head.SetProtocol(protocol)
+
+	for i, item := range testItems {
+		m.Head.SetSerNum(item.sernum)
+		m.Head.SetDevId(item.devid)
+		m.Arg.SetFuncCode(item.funccode)
+		m.Arg.SetRegAddr(item.regaddr)
+		m.Arg.SetRegLen(item.reglen)
+		testValuesCopy(&m.Arg, &item)
+
+		// 容量为零的切片
+		{
+			req, err := m.AppendRequest(nil)
+			if err != nil {
+				t.Fatalf("[%d] Failed to append %s request: %v.\n", i, m.Head.GetProtocolString(), err)
+			}
+			if expect := item.packet(protocol, true); !bytes.Equal(req, expect) {
+				t.Fatalf("[%d] %s request mismatch: Expected = %s, Actual = %s.\n", i, m.Head.GetProtocolString(), strFromHex(expect), strFromHex(req))
+			}
+		}
+
+		// 保留已有内容
+		{
+			prefix := []uint8{0xAA, 0xBB}
+			rsp, err := m.AppendResponse(prefix)
+			if err != nil {
+				t.Fatalf("[%d] Failed to append %s response: %v.\n", i, m.Head.GetProtocolString(), err)
+			}
+			expect := testFrame(protocol, item.packet(protocol, false), false)
+			if !bytes.Equal(rsp[:2], prefix) || !bytes.Equal(rsp[2:], expect) {
+				t.Fatalf("[%d] %s response mismatch: Expected = %s, Actual = %s.\n", i, m.Head.GetProtocolString(), strFromHex(expect), strFromHex(rsp))
+			}
+		}
+	}
+
+	// 失败时返回原始切片
+	m.Arg.SetFuncCode(0x00)
+	dst := []uint8{0x01}
+	if out, err := m.AppendRequest(dst); err == nil || !bytes.Equal(out, dst) {
+		t.Fatalf("AppendRequest with invalid function code: got %x, %v.\n", out, err)
+	}
+}
+
+// 初始化主站/从站共用的测试参数
+func testSetup(m *Modbus, protocol uint8, item *testItem) {
+	m.Head.SetProtocol(protocol)
+	m.Head.SetSerNum(item.sernum)
+	m.Head.SetDevId(item.devid)
+	m.Access.SetCheckCoil(checkDefault)
+	m.Access.SetCheckDiscrete(checkDefault)
+	m.Access.SetCheckHold(checkDefault)
+	m.Access.SetCheckInput(checkDefault)
+	m.Arg.SetFuncCode(item.funccode)
+	m.Arg.SetRegAddr(item.regaddr)
+	m.Arg.SetRegLen(item.reglen)
+	testValuesCopy(&m.Arg, item)
+}
+
+// testAllocs 测试封装/解析的内存分配次数
+func testAllocs(t *testing.T, protocol uint8, max float64) {
+	m := New()
+	buf := make([]uint8, 0, 1024)
+
+	for i := range testItems {
+		item := &testItems[i]
+		testSetup(m, protocol, item)
+		req := item.packet(protocol, true)
+		rsp := item.packet(protocol, false)
+
+		steps := []struct {
+			name string
+			fn   func()
+		}{
+			{"pack request", func() { _, _ = m.AppendRequest(buf) }},
+			{"pack response", func() { _, _ = m.AppendResponse(buf) }},
+			{"parse request", func() { _ = m.ParseRequest(req) }},
+			{"parse response", func() { _ = m.ParseResponse(rsp) }},
+		}
+		for _, step := range steps {
+			if n := testing.AllocsPerRun(100, step.fn); n > max {
+				t.Errorf("[%d] %s %s %s: %v allocs/op, want <= %v.\n", i, m.Head.GetProtocolString(), item.funccode2str(), step.name, n, max)
+			}
+		}
+	}
+}
+
+// benchmarkCodec 对每个功能码测试封装/解析的性能
+func benchmarkCodec(b *testing.B, protocol uint8) {
+	seen := make(map[uint8]bool)
+	for i := range testItems {
+		item := &testItems[i]
+		if seen[item.funccode] {
+			continue
+		}
+		seen[item.funccode] = true
+
+		m := New()
+		testSetup(m, protocol, item)
+		buf := make([]uint8, 0, 1024)
+		req := item.packet(protocol, true)
+		rsp := item.packet(protocol, false)
+
+		b.Run(item.funccode2str()+"/PackRequest", func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				_, _ = m.AppendRequest(buf)
+			}
+		})
+		b.Run(item.funccode2str()+"/PackResponse", func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				_, _ = m.AppendResponse(buf)
+			}
+		})
+		b.Run(item.funccode2str()+"/ParseRequest", func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				_ = m.ParseRequest(req)
+			}
+		})
+		b.Run(item.funccode2str()+"/ParseResponse", func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				_ = m.ParseResponse(rsp)
+			}
+		})
+	}
+}
+
+// 创建主站/从站
+func testPair(protocol uint8) (master, slave *Modbus) {
+	master, slave = New(), New()
+	master.Head.SetProtocol(protocol)
+	master.Head.SetDevId(0x01)
+	master.Head.SetSerNum(0x0001)
+	slave.Head.SetProtocol(protocol)
+	slave.Access.SetCheckCoil(checkDefault)
+	slave.Access.SetCheckDiscrete(checkDefault)
+	slave.Access.SetCheckHold(checkDefault)
+	slave.Access.SetCheckInput(checkDefault)
+	return master, slave
+}
+
+// 从 ADU 中提取 PDU
+func testPdu(protocol uint8, adu []uint8) []uint8 {
+	switch protocol {
+	case ProtocolRTU, ProtocolRtuOverTcp:
+		return adu[1 : len(adu)-2]
+	case ProtocolAscii, ProtocolAsciiOverTcp:
+		raw := strToHex(string(adu[1 : len(adu)-2]))
+		return raw[1 : len(raw)-1]
+	case ProtocolTCP:
+		return adu[7:]
+	}
+	return nil
+}
+
+// 去除测试报文中完整帧之后的多余数据
+func testFrame(protocol uint8, adu []uint8, isReq bool) []uint8 {
+	n := len(adu)
+	switch protocol {
+	case ProtocolRTU:
+		n = RTUFrameLen(adu, isReq)
+	case ProtocolAscii:
+		n = bytes.Index(adu, []uint8{EndHigh, EndLow}) + 2
+	case ProtocolTCP:
+		n = 6 + int(binary.BigEndian.Uint16(adu[4:6]))
+	}
+	if n <= 0 || n > len(adu) {
+		return adu
+	}
+	return adu[:n]
+}
+
+// testExchange 主站封装请求, 从站解析请求并处理后封装响应, 主站解析响应
+// reqPdu/rspPdu 不为空时检查请求/响应的 PDU
+func testExchange(t *testing.T, master, slave *Modbus, reqPdu, rspPdu string, serve func(s *Modbus)) error {
+	t.Helper()
+	protocol := master.Head.GetProtocol()
+
+	req, err := master.AppendRequest(nil)
+	if err != nil {
+		t.Fatalf("%s: failed to pack request: %v.\n", master.Head.GetProtocolString(), err)
+	}
+	if expect := strToHex(reqPdu); reqPdu != "" && !bytes.Equal(testPdu(protocol, req), expect) {
+		t.Fatalf("%s: request PDU mismatch: Expected = %x, Actual = %x.\n", master.Head.GetProtocolString(), expect, testPdu(protocol, req))
+	}
+
+	if err := slave.ParseRequest(req); err != nil {
+		t.Fatalf("%s: failed to parse request: %v.\n", slave.Head.GetProtocolString(), err)
+	}
+	if serve != nil && slave.Result.GetExcepCode() == ExcepNormal {
+		serve(slave)
+	}
+
+	rsp, err := slave.AppendResponse(nil)
+	if err != nil {
+		t.Fatalf("%s: failed to pack response: %v.\n", slave.Head.GetProtocolString(), err)
+	}
+	if expect := strToHex(rspPdu); rspPdu != "" && !bytes.Equal(testPdu(protocol, rsp), expect) {
+		t.Fatalf("%s: response PDU mismatch: Expected = %x, Actual = %x.\n", slave.Head.GetProtocolString(), expect, testPdu(protocol, rsp))
+	}
+
+	return master.ParseResponse(rsp)
+}
@@ -5,9 +5,16 @@
 
 package gromb
 
+const maxPduLen = 253 // PDU 最大长度
+
 // 封装 PDU 报文
 // 返回值: >=0-PDU报文长度,-1-失败
 func Pack(result *groResult, arg *groArg, box *groBox, isReq bool) int {
+	// 原始 PDU 模式
+	if arg.IsRawMode() {
+		return packRaw(result, arg, box)
+	}
+
 	// 检查是否回复异常
 	if !isReq && result.GetExcepCode() != ExcepNormal {
 		return packResponseExcep(result, arg, box)
@@ -62,7 +69,76 @@ func Pack(result *groResult, arg *groArg, box *groBox, isReq bool) int {
 		} else {
 			return packResponseReadInput(result, arg, box)
 		}
+	case FuncCodeMaskWriteHold:
+		if isReq {
+			return packRequestMaskWriteHold(result, arg, box)
+		} else {
+			return packResponseMaskWriteHold(result, arg, box)
+		}
+	case FuncCodeReadWriteHolds:
+		if isReq {
+			return packRequestReadWriteHolds(result, arg, box)
+		} else {
+			return packResponseReadWriteHolds(result, arg, box)
+		}
+	case FuncCodeReadExcepStatus:
+		if isReq {
+			return packRequestFuncOnly(result, arg, box)
+		} else {
+			return packResponseReadExcepStatus(result, arg, box)
+		}
+	case FuncCodeDiag:
+		if isReq {
+			return packRequestDiag(result, arg, box)
+		} else {
+			return packResponseDiag(result, arg, box)
+		}
+	case FuncCodeGetCommEventCounter:
+		if isReq {
+			return packRequestFuncOnly(result, arg, box)
+		} else {
+			return packResponseGetCommEventCounter(result, arg, box)
+		}
+	case FuncCodeGetCommEventLog:
+		if isReq {
+			return packRequestFuncOnly(result, arg, box)
+		} else {
+			return packResponseGetCommEventLog(result, arg, box)
+		}
+	case FuncCodeReportServerId:
+		if isReq {
+			return packRequestFuncOnly(result, arg, box)
+		} else {
+			return packResponseReportServerId(result, arg, box)
+		}
+	case FuncCodeReadFile:
+		if isReq {
+			return packRequestReadFile(result, arg, box)
+		} else {
+			return packResponseReadFile(result, arg, box)
+		}
+	case FuncCodeWriteFile:
+		if isReq {
+			return packRequestWriteFile(result, arg, box)
+		} else {
+			return packResponseWriteFile(result, arg, box)
+		}
+	case FuncCodeReadFifo:
+		if isReq {
+			return packRequestReadFifo(result, arg, box)
+		} else {
+			return packResponseReadFifo(result, arg, box)
+		}
+	case FuncCodeMei:
+		if isReq {
+			return packRequestReadDevIdent(result, arg, box)
+		} else {
+			return packResponseReadDevIdent(result, arg, box)
+		}
 	default:
+		if codec := lookupFuncCode(arg.GetFuncCode()); codec != nil {
+			return packCustom(result, arg, box, codec, isReq)
+		}
 		result.SetResult(ErrResultFuncCode)
 		return -1
 	}
@@ -71,9 +147,29 @@ func Pack(result *groResult, arg *groArg, box *groBox, isReq bool) int {
 // 解析 PDU 报文
 // 返回值: >=0-PDU报文长度,-1-失败
 func Parse(result *groResult, access *groAccess, arg *groArg, box *groBox, isReq bool) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 1 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
 	funccode := box.GetU8(0)
 	arg.SetFuncCode(funccode)
 
+	// 检查已认证的角色是否允许使用该功能码
+	// 拒绝时仍解析报文 (用于计算报文长度), 但不调用任何访问控制函数
+	if isReq && access.CheckRole != nil && !access.CheckRole(access.Role, funccode, access.UserData) {
+		ret := parseFunc(result, &groAccess{}, arg, box, funccode, isReq)
+		if ret >= 0 {
+			result.SetExcepCode(ExcepIllFuncCode)
+		}
+		return ret
+	}
+	return parseFunc(result, access, arg, box, funccode, isReq)
+}
+
+// 依据功能码解析 PDU 报文
+func parseFunc(result *groResult, access *groAccess, arg *groArg, box *groBox, funccode uint8, isReq bool) int {
 	switch funccode {
 	case FuncCodeReadCoil:
 		if isReq {
@@ -123,6 +219,72 @@ func Parse(result *groResult, access *groAccess, arg *groArg, box *groBox, isReq
 		} else {
 			return parseResponseReadInput(result, arg, box)
 		}
+	case FuncCodeMaskWriteHold:
+		if isReq {
+			return parseRequestMaskWriteHold(result, access, arg, box)
+		} else {
+			return parseResponseMaskWriteHold(result, arg, box)
+		}
+	case FuncCodeReadWriteHolds:
+		if isReq {
+			return parseRequestReadWriteHolds(result, access, arg, box)
+		} else {
+			return parseResponseReadWriteHolds(result, arg, box)
+		}
+	case FuncCodeReadExcepStatus:
+		if isReq {
+			return parseRequestFuncOnly(result, access, arg, box)
+		} else {
+			return parseResponseReadExcepStatus(result, arg, box)
+		}
+	case FuncCodeDiag:
+		if isReq {
+			return parseRequestDiag(result, access, arg, box)
+		} else {
+			return parseResponseDiag(result, arg, box)
+		}
+	case FuncCodeGetCommEventCounter:
+		if isReq {
+			return parseRequestFuncOnly(result, access, arg, box)
+		} else {
+			return parseResponseGetCommEventCounter(result, arg, box)
+		}
+	case FuncCodeGetCommEventLog:
+		if isReq {
+			return parseRequestFuncOnly(result, access, arg, box)
+		} else {
+			return parseResponseGetCommEventLog(result, arg, box)
+		}
+	case FuncCodeReportServerId:
+		if isReq {
+			return parseRequestFuncOnly(result, access, arg, box)
+		} else {
+			return parseResponseReportServerId(result, arg, box)
+		}
+	case FuncCodeReadFile:
+		if isReq {
+			return parseRequestReadFile(result, access, arg, box)
+		} else {
+			return parseResponseReadFile(result, arg, box)
+		}
+	case FuncCodeWriteFile:
+		if isReq {
+			return parseRequestWriteFile(result, access, arg, box)
+		} else {
+			return parseResponseWriteFile(result, arg, box)
+		}
+	case FuncCodeReadFifo:
+		if isReq {
+			return parseRequestReadFifo(result, access, arg, box)
+		} else {
+			return parseResponseReadFifo(result, arg, box)
+		}
+	case FuncCodeMei:
+		if isReq {
+			return parseRequestReadDevIdent(result, access, arg, box)
+		} else {
+			return parseResponseReadDevIdent(result, arg, box)
+		}
 	case FuncCodeReadCoil | 0x80,
 		FuncCodeWriteCoil | 0x80,
 		FuncCodeWriteCoils | 0x80,
@@ -130,7 +292,18 @@ func Parse(result *groResult, access *groAccess, arg *groArg, box *groBox, isReq
 		FuncCodeReadHold | 0x80,
 		FuncCodeWriteHold | 0x80,
 		FuncCodeWriteHolds | 0x80,
-		FuncCodeReadInput | 0x80:
+		FuncCodeReadInput | 0x80,
+		FuncCodeMaskWriteHold | 0x80,
+		FuncCodeReadWriteHolds | 0x80,
+		FuncCodeReadExcepStatus | 0x80,
+		FuncCodeDiag | 0x80,
+		FuncCodeGetCommEventCounter | 0x80,
+		FuncCodeGetCommEventLog | 0x80,
+		FuncCodeReportServerId | 0x80,
+		FuncCodeReadFile | 0x80,
+		FuncCodeWriteFile | 0x80,
+		FuncCodeReadFifo | 0x80,
+		FuncCodeMei | 0x80:
 		if isReq {
 			result.SetResult(ErrResultFuncCode)
 			return -1
@@ -138,6 +311,14 @@ func Parse(result *groResult, access *groAccess, arg *groArg, box *groBox, isReq
 			return parseResponseExcep(result, box)
 		}
 	default:
+		// 自定义功能码
+		if codec := lookupFuncCode(funccode &^ 0x80); codec != nil {
+			if funccode&0x80 == 0 {
+				return parseCustom(result, arg, box, codec, isReq)
+			} else if !isReq {
+				return parseResponseExcep(result, box)
+			}
+		}
 		result.SetResult(ErrResultFuncCode)
 		return -1
 	}
@@ -9,6 +9,11 @@ import (
 	"encoding/binary"
 )
 
+const (
+	maxReadBits   = 0x07D0 // 读线圈/离散量输入的最大数量
+	maxWriteCoils = 0x07B0 // 写多个线圈的最大数量
+)
+
 // <--------------- MODBUS Read Coils Request PDU ------------------->
 // +-------------------+---------------------+-----------------------+
 // | Function Code     | Starting Address    | Quantity of Coils     |
@@ -21,7 +26,7 @@ func packRequestReadCoil(result *groResult, arg *groArg, box *groBox) int {
 	reglen := arg.GetRegLen()
 
 	// 检查参数
-	if reglen < 0x0001 || reglen > 0x07D0 {
+	if reglen < 0x0001 || reglen > maxReadBits {
 		result.SetResult(ErrResultRegLen)
 		return -1
 	}
@@ -45,11 +50,11 @@ func parseRequestReadCoil(result *groResult, access *groAccess, arg *groArg, box
 	reglen := box.GetU16(3, binary.BigEndian)
 
 	// 检查参数
-	if reglen < 0x0001 || reglen > 0x07D0 {
+	if reglen < 0x0001 || reglen > maxReadBits {
 		result.SetExcepCode(ExcepIllDataValue)
 	} else if access.CheckCoil == nil {
 		result.SetExcepCode(ExcepIllFuncCode)
-	} else if !access.CheckCoil(regaddr, reglen, true, access.UserData) {
+	} else if !access.check(TableCoil, regaddr, reglen, true) {
 		result.SetExcepCode(ExcepIllDataAddr)
 	} else {
 		arg.SetRegAddr(regaddr)
@@ -70,7 +75,7 @@ func packResponseReadCoil(result *groResult, arg *groArg, box *groBox) int {
 	number := (reglen + 7) / 8 // 字节数
 
 	// 检查参数
-	if reglen < 0x0001 || reglen > 0x07D0 {
+	if reglen < 0x0001 || reglen > maxReadBits {
 		result.SetResult(ErrResultRegLen)
 		return -1
 	}
@@ -145,16 +150,16 @@ func parseRequestWriteCoil(result *groResult, access *groAccess, arg *groArg, bo
 	// 检查参数
 	if access.CheckCoil == nil {
 		result.SetExcepCode(ExcepIllFuncCode)
-	} else if !access.CheckCoil(regaddr, 1, false, access.UserData) {
+	} else if !access.check(TableCoil, regaddr, 1, false) {
 		result.SetExcepCode(ExcepIllDataAddr)
 	} else if value == 0x0000 {
 		arg.SetRegAddr(regaddr)
 		arg.SetRegLen(1)
-		arg.SetU8s([]uint8{0x00})
+		arg.alloc(1)[0] = 0x00
 	} else if value == 0xFF00 {
 		arg.SetRegAddr(regaddr)
 		arg.SetRegLen(1)
-		arg.SetU8s([]uint8{0x01})
+		arg.alloc(1)[0] = 0x01
 	} else {
 		result.SetExcepCode(ExcepIllDataValue)
 	}
@@ -199,6 +204,10 @@ func parseResponseWriteCoil(result *groResult, arg *groArg, box *groBox) int {
 	}
 
 	// 检查线圈状态值
+	if len(arg.GetU8s()) < 1 {
+		result.SetResult(ErrResultRegValue)
+		return -1
+	}
 	{
 		v1 := box.GetU16(3, binary.BigEndian) == 0xFF00
 		v2 := arg.GetU8(0)&0x01 != 0
@@ -223,7 +232,7 @@ func packRequestWriteCoils(result *groResult, arg *groArg, box *groBox) int {
 	reglen := arg.GetRegLen()
 	number := (reglen + 7) / 8
 
-	if reglen < 0x0001 || reglen > 0x07B0 {
+	if reglen < 0x0001 || reglen > maxWriteCoils {
 		result.SetResult(ErrResultRegLen)
 		return -1
 	}
@@ -254,11 +263,11 @@ func parseRequestWriteCoils(result *groResult, access *groAccess, arg *groArg, b
 		return -1
 	}
 
-	if reglen < 0x0001 || reglen > 0x07B0 || number != uint16(box.GetU8(5)) {
+	if reglen < 0x0001 || reglen > maxWriteCoils || number != uint16(box.GetU8(5)) {
 		result.SetExcepCode(ExcepIllDataValue)
 	} else if access.CheckCoil == nil {
 		result.SetExcepCode(ExcepIllFuncCode)
-	} else if !access.CheckCoil(regaddr, reglen, false, access.UserData) {
+	} else if !access.check(TableCoil, regaddr, reglen, false) {
 		result.SetExcepCode(ExcepIllDataAddr)
 	} else {
 		arg.SetRegAddr(regaddr)
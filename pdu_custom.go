@@ -0,0 +1,120 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"errors"
+	"sync"
+)
+
+// 自定义功能码的编解码器
+// 报文数据 (不含功能码) 与 Arg.GetU8s()/Arg.SetU8s() 之间的转换由编解码器实现
+//
+// 封装函数: data 为 Arg.GetU8s(), 将报文数据追加到 dst (已封装的报文) 尾部并返回追加后的切片, 同 append
+// 解析函数: pdu 为功能码之后的报文数据, 已依据 ADU 长度截取 (不含 CRC/LRC 校验码等尾部数据),
+// 返回值 n 为报文数据的实际长度 (通常为 len(pdu)), data 保存至 Arg.SetU8s()
+//
+// 解析请求报文时返回 *ExceptionError (如 ErrIllDataAddr) 将回复对应的异常响应
+type FuncCodec struct {
+	Name          string                                             // 名称 (FuncCodeToString)
+	Broadcast     bool                                               // 是否允许广播 (如写请求), 默认不允许
+	PackRequest   func(dst, data []uint8) ([]uint8, error)           // 封装请求报文
+	ParseRequest  func(pdu []uint8) (n int, data []uint8, err error) // 解析请求报文
+	PackResponse  func(dst, data []uint8) ([]uint8, error)           // 封装响应报文
+	ParseResponse func(pdu []uint8) (n int, data []uint8, err error) // 解析响应报文
+}
+
+var (
+	funcCodecsMu sync.RWMutex
+	funcCodecs   = map[uint8]*FuncCodec{}
+)
+
+// 注册自定义功能码 (如用户自定义功能码 65~72, 100~110)
+// 注册后功能码可用于 Pack/Parse, 自动支持异常响应 (code|0x80) 与 FuncCodeToString
+// 重复注册将替换之前的编解码器, 不允许替换内置功能码
+// 返回值: 功能码无效或为内置功能码时返回 ErrResultFuncCode
+func RegisterFuncCode(code uint8, codec FuncCodec) error {
+	if code == 0 || code&0x80 != 0 {
+		return ErrResultFuncCode
+	}
+	if lookupFuncCode(code) == nil && FuncCodeToString(code) != "unknown function code" {
+		return ErrResultFuncCode
+	}
+
+	funcCodecsMu.Lock()
+	defer funcCodecsMu.Unlock()
+	funcCodecs[code] = &codec
+	return nil
+}
+
+// 查找自定义功能码的编解码器
+func lookupFuncCode(code uint8) *FuncCodec {
+	funcCodecsMu.RLock()
+	defer funcCodecsMu.RUnlock()
+	return funcCodecs[code]
+}
+
+// 封装报文-自定义功能码
+func packCustom(result *groResult, arg *groArg, box *groBox, codec *FuncCodec, isReq bool) int {
+	pack := codec.PackResponse
+	if isReq {
+		pack = codec.PackRequest
+	}
+	if pack == nil {
+		result.SetResult(ErrResultFuncCode)
+		return -1
+	}
+
+	// 数据直接追加到报文缓冲区, 避免内存分配
+	box.PutU8(arg.GetFuncCode())
+	start := len(box.buffer)
+	buf, err := pack(box.buffer, arg.GetU8s())
+	if err == nil && (len(buf) < start || len(buf)-start+1 > maxPduLen) {
+		err = ErrResultLength
+	}
+	if err != nil {
+		box.buffer = box.buffer[:start-1]
+		result.SetResult(err)
+		return -1
+	}
+	box.buffer = buf
+	return 1 + len(buf) - start
+}
+
+// 解析报文-自定义功能码
+func parseCustom(result *groResult, arg *groArg, box *groBox, codec *FuncCodec, isReq bool) int {
+	parse := codec.ParseResponse
+	if isReq {
+		parse = codec.ParseRequest
+	}
+	if parse == nil {
+		if isReq {
+			result.SetExcepCode(ExcepIllFuncCode)
+			return 1
+		}
+		result.SetResult(ErrResultFuncCode)
+		return -1
+	}
+
+	pdu := box.GetThisBuffer(1, box.ThisSize())
+	n, data, err := parse(pdu)
+	if err != nil {
+		var excep *ExceptionError
+		if isReq && errors.As(err, &excep) && n >= 0 && n <= len(pdu) {
+			result.SetExcepCode(excep.Code)
+			return 1 + n
+		}
+		result.SetResult(err)
+		return -1
+	}
+	if n < 0 || n > len(pdu) {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	arg.SetU8s(data)
+	return 1 + n
+}
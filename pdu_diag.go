@@ -0,0 +1,441 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"encoding/binary"
+)
+
+// <- MODBUS Read Exception Status / Get Comm Event Counter / Get Comm Event Log / Report Server ID Request PDU ->
+// +-------------------+
+// | Function Code     |
+// | 1 Byte            |
+// +-------------------+
+
+// 封装请求报文-仅功能码 (读异常状态/获取通信事件计数/获取通信事件日志/报告从机标识)
+func packRequestFuncOnly(result *groResult, arg *groArg, box *groBox) int {
+	_ = result
+	box.PutU8(arg.GetFuncCode())
+	return 1
+}
+
+// 解析请求报文-仅功能码 (读异常状态/获取通信事件计数/获取通信事件日志/报告从机标识)
+// 响应数据由 Modbus.ParseRequest 依据诊断计数器填充
+func parseRequestFuncOnly(result *groResult, access *groAccess, arg *groArg, box *groBox) int {
+	_, _ = result, access
+	return 1
+}
+
+// <------ MODBUS Read Exception Status Response PDU ------>
+// +-------------------+--------------------+
+// | Function Code     | Output Data        |
+// | 1 Byte            | 1 Byte             |
+// +-------------------+--------------------+
+
+// 封装响应报文-读异常状态
+func packResponseReadExcepStatus(result *groResult, arg *groArg, box *groBox) int {
+	// 检查参数
+	if len(arg.GetU8s()) < 1 {
+		result.SetResult(ErrResultRegValue)
+		return -1
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeReadExcepStatus)
+	box.PutU8(arg.GetU8(0))
+	return 2
+}
+
+// 解析响应报文-读异常状态
+func parseResponseReadExcepStatus(result *groResult, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 2 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	arg.SetU8s(box.GetThisBuffer(1, 2))
+	return 2
+}
+
+// <--------------------- MODBUS Diagnostics Request/Response PDU -------------------->
+// +-------------------+--------------------+--------------------+
+// | Function Code     | Sub-function       | Data               |
+// | 1 Byte            | 2 Bytes            | N x 2 Bytes        |
+// +-------------------+--------------------+--------------------+
+// 仅子功能 0x00 (返回查询数据) 的数据为 N x 2 字节, 其他子功能固定为 2 字节
+
+// 诊断报文的结束位置 (PDU 中的偏移)
+// 子功能 0x00 的回送数据长度由 ADU 确定, 即 PDU 的全部剩余数据
+// 返回值: 数据长度不是 2 的整数倍时返回 false
+func diagEnd(box *groBox) (uint16, bool) {
+	if box.GetU16(1, binary.BigEndian) != DiagReturnQueryData {
+		return 5, true
+	}
+	end := box.ThisSize()
+	return end, end >= 5 && (end-3)%2 == 0
+}
+
+// 封装请求报文-诊断
+func packRequestDiag(result *groResult, arg *groArg, box *groBox) int {
+	return packDiag(result, arg, box)
+}
+
+// 解析请求报文-诊断
+// 子功能由 Modbus.ParseRequest 依据诊断计数器处理
+func parseRequestDiag(result *groResult, access *groAccess, arg *groArg, box *groBox) int {
+	_ = access
+
+	// 检查报文是否过短
+	if box.ThisSize() < 5 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	end, ok := diagEnd(box)
+	if !ok {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	arg.SetSubFunc(box.GetU16(1, binary.BigEndian))
+	arg.SetU8s(box.GetU8s(3, end))
+	return int(end)
+}
+
+// 封装响应报文-诊断
+func packResponseDiag(result *groResult, arg *groArg, box *groBox) int {
+	return packDiag(result, arg, box)
+}
+
+// 解析响应报文-诊断
+func parseResponseDiag(result *groResult, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 5 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	// 检查子功能码
+	if box.GetU16(1, binary.BigEndian) != arg.GetSubFunc() {
+		result.SetResult(ErrResultFuncCode)
+		return -1
+	}
+
+	end, ok := diagEnd(box)
+	if !ok {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	arg.SetU8s(box.GetThisBuffer(3, end))
+	return int(end)
+}
+
+// 封装诊断报文 (请求与响应格式相同)
+func packDiag(result *groResult, arg *groArg, box *groBox) int {
+	// 检查参数: 子功能 0x00 回送全部数据 (N x 2 字节), 其他子功能为 2 字节
+	data := arg.GetU8s()
+	number := 2
+	if arg.GetSubFunc() == DiagReturnQueryData {
+		number = len(data)
+	}
+	if len(data) < 2 || number%2 != 0 || 3+number > maxPduLen {
+		result.SetResult(ErrResultRegValue)
+		return -1
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeDiag)
+	box.PutU16(arg.GetSubFunc(), binary.BigEndian)
+	box.PutU8s(data[0:number])
+	return 3 + number
+}
+
+// <----------------- MODBUS Get Comm Event Counter Response PDU ------------------>
+// +-------------------+--------------------+--------------------+
+// | Function Code     | Status             | Event Count        |
+// | 1 Byte            | 2 Bytes            | 2 Bytes            |
+// +-------------------+--------------------+--------------------+
+
+// 封装响应报文-获取通信事件计数
+func packResponseGetCommEventCounter(result *groResult, arg *groArg, box *groBox) int {
+	// 检查参数
+	if len(arg.GetU8s()) < 4 {
+		result.SetResult(ErrResultRegValue)
+		return -1
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeGetCommEventCounter)
+	box.PutU8s(arg.GetU8s()[0:4])
+	return 5
+}
+
+// 解析响应报文-获取通信事件计数
+func parseResponseGetCommEventCounter(result *groResult, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 5 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	arg.SetU8s(box.GetThisBuffer(1, 5))
+	return 5
+}
+
+// <--------------------------------- MODBUS Get Comm Event Log Response PDU ---------------------------------->
+// +---------------+---------------+---------------+---------------+---------------+---------------+
+// | Function Code | Byte Count    | Status        | Event Count   | Message Count | Events        |
+// | 1 Byte        | 1 Byte        | 2 Bytes       | 2 Bytes       | 2 Bytes       | 0~64 Bytes    |
+// +---------------+---------------+---------------+---------------+---------------+---------------+
+
+// 封装响应报文-获取通信事件日志
+func packResponseGetCommEventLog(result *groResult, arg *groArg, box *groBox) int {
+	number := len(arg.GetU8s())
+
+	// 检查参数
+	if number < 6 || number > 6+maxCommEvents {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeGetCommEventLog)
+	box.PutU8(uint8(number))
+	box.PutU8s(arg.GetU8s())
+	return 2 + number
+}
+
+// 解析响应报文-获取通信事件日志
+func parseResponseGetCommEventLog(result *groResult, arg *groArg, box *groBox) int {
+	return parseResponseByteCount(result, arg, box, 6, 6+maxCommEvents)
+}
+
+// <----------------------- MODBUS Report Server ID Response PDU ------------------------>
+// +-------------------+--------------------+----------------------------------------+
+// | Function Code     | Byte Count         | Server ID + Run Indicator + Additional |
+// | 1 Byte            | 1 Byte             | n Bytes                                |
+// +-------------------+--------------------+----------------------------------------+
+
+// 封装响应报文-报告从机标识
+func packResponseReportServerId(result *groResult, arg *groArg, box *groBox) int {
+	number := len(arg.GetU8s())
+
+	// 检查参数
+	if number < 1 || 2+number > maxPduLen {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeReportServerId)
+	box.PutU8(uint8(number))
+	box.PutU8s(arg.GetU8s())
+	return 2 + number
+}
+
+// 解析响应报文-报告从机标识
+func parseResponseReportServerId(result *groResult, arg *groArg, box *groBox) int {
+	return parseResponseByteCount(result, arg, box, 1, maxPduLen-2)
+}
+
+// 解析 "功能码 + 字节数 + 数据" 格式的响应报文
+func parseResponseByteCount(result *groResult, arg *groArg, box *groBox, min, max uint16) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 2 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	// 检查字节数
+	number := uint16(box.GetU8(1))
+	if number < min || number > max {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+	if box.ThisSize() < 2+number {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	arg.SetU8s(box.GetThisBuffer(2, 2+number))
+	return 2 + int(number)
+}
+
+// 处理串行链路诊断请求 (从机)
+// 只听模式下除重启通信选项外的请求均不响应
+// Modbus TCP (含 UDP 与 TLS) 没有串行链路: 不进入只听模式, 串行链路专用的功能码回复非法功能码异常
+func (m *Modbus) serveDiag() {
+	funccode := m.Arg.GetFuncCode()
+	subfunc := m.Arg.GetSubFunc()
+	diag := &m.Diag
+
+	if m.Head.GetProtocol() == ProtocolTCP {
+		switch funccode {
+		case FuncCodeReadExcepStatus, FuncCodeDiag, FuncCodeGetCommEventCounter, FuncCodeGetCommEventLog, FuncCodeReportServerId:
+			if m.Result.GetExcepCode() == ExcepNormal {
+				m.Result.SetExcepCode(ExcepIllFuncCode)
+			}
+		}
+		return
+	}
+
+	if diag.IsListenOnly() && (funccode != FuncCodeDiag || subfunc != DiagRestartComm) {
+		m.Result.SetNoResponse(true)
+		diag.counters.ServerNoResp++
+		return
+	}
+	if m.Result.GetExcepCode() != ExcepNormal {
+		return
+	}
+
+	switch funccode {
+	case FuncCodeReadExcepStatus:
+		m.Arg.alloc(1)[0] = diag.GetExcepStatus()
+	case FuncCodeGetCommEventCounter:
+		m.Arg.SetU16s([]uint16{0x0000, diag.counters.CommEvent}, binary.BigEndian)
+	case FuncCodeGetCommEventLog:
+		buf := m.Arg.alloc(6 + diag.nevent)
+		binary.BigEndian.PutUint16(buf[0:], 0x0000)
+		binary.BigEndian.PutUint16(buf[2:], diag.counters.CommEvent)
+		binary.BigEndian.PutUint16(buf[4:], diag.counters.BusMessage)
+		diag.appendEvents(buf[:6])
+	case FuncCodeReportServerId:
+		if len(diag.GetServerId()) == 0 {
+			m.Result.SetExcepCode(ExcepIllFuncCode)
+			return
+		}
+		m.Arg.SetU8s(diag.GetServerId())
+	case FuncCodeDiag:
+		m.serveDiagSubFunc(subfunc)
+	}
+}
+
+// 处理诊断子功能 (从机)
+func (m *Modbus) serveDiagSubFunc(subfunc uint16) {
+	diag := &m.Diag
+	data := m.Arg.GetU16(0, binary.BigEndian)
+
+	var value uint16
+	switch subfunc {
+	case DiagReturnQueryData:
+		return
+	case DiagRestartComm:
+		if data != 0x0000 && data != 0xFF00 {
+			m.Result.SetExcepCode(ExcepIllDataValue)
+			return
+		}
+		// 处于只听模式时仅退出只听模式, 不响应
+		if diag.IsListenOnly() {
+			m.Result.SetNoResponse(true)
+		}
+		diag.restart(data == 0xFF00)
+		return
+	case DiagChangeAsciiDelimiter:
+		if data&0x00FF != 0 {
+			m.Result.SetExcepCode(ExcepIllDataValue)
+			return
+		}
+		m.Head.SetAsciiEnd(uint8(data >> 8))
+		return
+	case DiagForceListenOnly:
+		diag.forceListenOnly()
+		m.Result.SetNoResponse(true)
+		return
+	case DiagClearCounters:
+		diag.ClearCounters()
+		return
+	case DiagClearOverrunCounter:
+		diag.counters.BusCharOverrun = 0
+		return
+	case DiagReturnRegister:
+		value = diag.GetRegister()
+	case DiagBusMessageCount:
+		value = diag.counters.BusMessage
+	case DiagBusCommErrorCount:
+		value = diag.counters.BusCommError
+	case DiagBusExcepErrorCount:
+		value = diag.counters.BusExcepError
+	case DiagServerMessageCount:
+		value = diag.counters.ServerMessage
+	case DiagServerNoResponseCount:
+		value = diag.counters.ServerNoResp
+	case DiagServerNakCount:
+		value = diag.counters.ServerNak
+	case DiagServerBusyCount:
+		value = diag.counters.ServerBusy
+	case DiagBusCharOverrunCount:
+		value = diag.counters.BusCharOverrun
+	default:
+		m.Result.SetExcepCode(ExcepIllFuncCode)
+		return
+	}
+	m.Arg.SetU16s([]uint16{value}, binary.BigEndian)
+}
+
+// 通信事件日志
+type CommEventLog struct {
+	Status       uint16  // 状态 (0xFFFF-从机忙)
+	EventCount   uint16  // 通信事件计数
+	MessageCount uint16  // 总线报文计数
+	Events       []uint8 // 事件 (最新的事件在前)
+}
+
+// 读异常状态 (主站)
+func (m *Modbus) ReadExcepStatus(transact Transact) (uint8, error) {
+	m.Arg.SetFuncCode(FuncCodeReadExcepStatus)
+	if err := m.transact(transact); err != nil {
+		return 0, err
+	}
+	return m.Arg.GetU8(0), nil
+}
+
+// 诊断 (主站)
+// 返回值: 响应中的数据字段
+// 注意: 强制只听模式 (DiagForceListenOnly) 无响应, 应直接封装请求报文发送
+func (m *Modbus) Diagnostic(subfunc, data uint16, transact Transact) (uint16, error) {
+	m.Arg.SetFuncCode(FuncCodeDiag)
+	m.Arg.SetSubFunc(subfunc)
+	m.Arg.SetU16s([]uint16{data}, binary.BigEndian)
+	if err := m.transact(transact); err != nil {
+		return 0, err
+	}
+	return m.Arg.GetU16(0, binary.BigEndian), nil
+}
+
+// 获取通信事件计数 (主站)
+func (m *Modbus) GetCommEventCounter(transact Transact) (status, count uint16, err error) {
+	m.Arg.SetFuncCode(FuncCodeGetCommEventCounter)
+	if err := m.transact(transact); err != nil {
+		return 0, 0, err
+	}
+	return m.Arg.GetU16(0, binary.BigEndian), m.Arg.GetU16(2, binary.BigEndian), nil
+}
+
+// 获取通信事件日志 (主站)
+func (m *Modbus) GetCommEventLog(transact Transact) (*CommEventLog, error) {
+	m.Arg.SetFuncCode(FuncCodeGetCommEventLog)
+	if err := m.transact(transact); err != nil {
+		return nil, err
+	}
+	return &CommEventLog{
+		Status:       m.Arg.GetU16(0, binary.BigEndian),
+		EventCount:   m.Arg.GetU16(2, binary.BigEndian),
+		MessageCount: m.Arg.GetU16(4, binary.BigEndian),
+		Events:       append([]uint8(nil), m.Arg.GetU8s()[6:]...),
+	}, nil
+}
+
+// 报告从机标识 (主站)
+// 返回值: 从机标识数据 (内容由设备定义)
+func (m *Modbus) ReportServerId(transact Transact) ([]uint8, error) {
+	m.Arg.SetFuncCode(FuncCodeReportServerId)
+	if err := m.transact(transact); err != nil {
+		return nil, err
+	}
+	return append([]uint8(nil), m.Arg.GetU8s()...), nil
+}
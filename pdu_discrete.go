@@ -21,7 +21,7 @@ func packRequestReadDiscretes(result *groResult, arg *groArg, box *groBox) int {
 	reglen := arg.GetRegLen()
 
 	// 检查参数
-	if reglen < 0x0001 || reglen > 0x07D0 {
+	if reglen < 0x0001 || reglen > maxReadBits {
 		result.SetResult(ErrResultRegLen)
 		return -1
 	}
@@ -45,11 +45,11 @@ func parseRequestReadDiscretes(result *groResult, access *groAccess, arg *groArg
 	reglen := box.GetU16(3, binary.BigEndian)
 
 	// 检查参数
-	if reglen < 0x0001 || reglen > 0x07D0 {
+	if reglen < 0x0001 || reglen > maxReadBits {
 		result.SetExcepCode(ExcepIllDataValue)
 	} else if access.CheckDiscrete == nil {
 		result.SetExcepCode(ExcepIllFuncCode)
-	} else if !access.CheckDiscrete(regaddr, reglen, true, access.UserData) {
+	} else if !access.check(TableDiscrete, regaddr, reglen, true) {
 		result.SetExcepCode(ExcepIllDataAddr)
 	} else {
 		arg.SetRegAddr(regaddr)
@@ -70,7 +70,7 @@ func packResponseReadDiscretes(result *groResult, arg *groArg, box *groBox) int
 	number := (reglen + 7) / 8 // 字节数
 
 	// 检查参数
-	if reglen < 0x0001 || reglen > 0x07D0 {
+	if reglen < 0x0001 || reglen > maxReadBits {
 		result.SetResult(ErrResultRegLen)
 		return -1
 	}
@@ -0,0 +1,106 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"encoding/binary"
+)
+
+const maxFifoCount = 31 // 先进先出队列最大寄存器数量
+
+// <------ MODBUS Read FIFO Queue Request PDU ------->
+// +-------------------+----------------------------+
+// | Function Code     | FIFO Pointer Address       |
+// | 1 Byte            | 2 Bytes                    |
+// +-------------------+----------------------------+
+
+// 封装请求报文-读先进先出队列
+func packRequestReadFifo(result *groResult, arg *groArg, box *groBox) int {
+	_ = result
+	regaddr := arg.GetRegAddr()
+
+	// 填充报文
+	box.PutU8(FuncCodeReadFifo)
+	box.PutU16(regaddr, binary.BigEndian)
+	return 3
+}
+
+// 解析请求报文-读先进先出队列
+func parseRequestReadFifo(result *groResult, access *groAccess, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 3 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	regaddr := box.GetU16(1, binary.BigEndian) // 队列指针地址
+
+	// 检查参数
+	if access.ReadFifo == nil {
+		result.SetExcepCode(ExcepIllFuncCode)
+	} else if values, ok := access.ReadFifo(regaddr, access.UserData); !ok {
+		result.SetExcepCode(ExcepIllDataAddr)
+	} else if len(values) > maxFifoCount {
+		result.SetExcepCode(ExcepIllDataValue)
+	} else {
+		arg.SetRegAddr(regaddr)
+		arg.SetRegLen(uint16(len(values)))
+		arg.SetU16s(values, binary.BigEndian)
+	}
+	return 3
+}
+
+// <------------------------- MODBUS Read FIFO Queue Response PDU ------------------------->
+// +-------------------+--------------------+--------------------+--------------------+
+// | Function Code     | Byte Count         | FIFO Count         | FIFO Value Register|
+// | 1 Byte            | 2 Bytes            | 2 Bytes            | N*2 Bytes          |
+// +-------------------+--------------------+--------------------+--------------------+
+
+// 封装响应报文-读先进先出队列
+func packResponseReadFifo(result *groResult, arg *groArg, box *groBox) int {
+	reglen := arg.GetRegLen() // 队列寄存器数量
+	number := reglen * 2      // 寄存器值字节数
+
+	// 检查参数
+	if reglen > maxFifoCount || len(arg.GetU8s()) < int(number) {
+		result.SetResult(ErrResultRegLen)
+		return -1
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeReadFifo)
+	box.PutU16(2+number, binary.BigEndian)
+	box.PutU16(reglen, binary.BigEndian)
+	box.PutU8s(arg.GetU8s()[0:number])
+
+	return 5 + int(number)
+}
+
+// 解析响应报文-读先进先出队列
+func parseResponseReadFifo(result *groResult, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 5 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	// 检查字节数/队列寄存器数量是否错误
+	number := box.GetU16(1, binary.BigEndian)
+	reglen := box.GetU16(3, binary.BigEndian)
+	if reglen > maxFifoCount || number != 2+reglen*2 {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+	if box.ThisSize() < 3+number {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	arg.SetRegLen(reglen)
+	arg.SetU8s(box.GetThisBuffer(5, 3+number))
+
+	return 3 + int(number)
+}
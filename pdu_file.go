@@ -0,0 +1,368 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	fileRefType   = 0x06   // 文件记录参考类型
+	maxFileRecord = 0x270F // 最大记录号
+)
+
+// <------------------------------------ MODBUS Read File Record Request PDU ------------------------------------->
+// +---------------+---------------+---------------+---------------+---------------+---------------+---------------+
+// | Function Code | Byte Count    | Reference Type| File Number   | Record Number | Record Length | ...           |
+// | 1 Byte        | 1 Byte        | 1 Byte        | 2 Bytes       | 2 Bytes       | 2 Bytes       | (Sub-Request) |
+// +---------------+---------------+---------------+---------------+---------------+---------------+---------------+
+
+// 封装请求报文-读文件记录
+func packRequestReadFile(result *groResult, arg *groArg, box *groBox) int {
+	records := arg.GetFileRecords()
+	number := 7 * len(records) // 字节数
+
+	// 检查参数
+	if len(records) < 1 {
+		result.SetResult(ErrResultRegLen)
+		return -1
+	} else if number > 0xF5 || fileReadRspLen(records) > maxPduLen {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+	for _, rec := range records {
+		if rec.Length < 0x0001 {
+			result.SetResult(ErrResultRegLen)
+			return -1
+		} else if !checkFileRecord(rec.Record, rec.Length) {
+			result.SetResult(ErrResultRegAddr)
+			return -1
+		}
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeReadFile)
+	box.PutU8(uint8(number))
+	for _, rec := range records {
+		box.PutU8(fileRefType)
+		box.PutU16(rec.File, binary.BigEndian)
+		box.PutU16(rec.Record, binary.BigEndian)
+		box.PutU16(rec.Length, binary.BigEndian)
+	}
+	return 2 + number
+}
+
+// 解析请求报文-读文件记录
+// 通过 access.FileStore 读取各个子请求的记录数据
+func parseRequestReadFile(result *groResult, access *groAccess, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 2 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+	number := uint16(box.GetU8(1))
+	if box.ThisSize() < 2+number {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	// 检查参数
+	if number < 0x07 || number > 0xF5 || number%7 != 0 {
+		result.SetExcepCode(ExcepIllDataValue)
+		return 2 + int(number)
+	} else if access.FileStore == nil {
+		result.SetExcepCode(ExcepIllFuncCode)
+		return 2 + int(number)
+	}
+
+	arg.records = arg.records[:0]
+	for offset := uint16(2); offset < 2+number; offset += 7 {
+		rec := FileRecord{
+			File:   box.GetU16(offset+1, binary.BigEndian),
+			Record: box.GetU16(offset+3, binary.BigEndian),
+			Length: box.GetU16(offset+5, binary.BigEndian),
+		}
+		if box.GetU8(offset) != fileRefType || rec.Length < 0x0001 || !checkFileRecord(rec.Record, rec.Length) {
+			result.SetExcepCode(ExcepIllDataAddr)
+			return 2 + int(number)
+		}
+		arg.records = append(arg.records, rec)
+	}
+	if fileReadRspLen(arg.records) > maxPduLen {
+		result.SetExcepCode(ExcepIllDataValue)
+		return 2 + int(number)
+	}
+
+	// 读取记录数据
+	size := 0
+	for _, rec := range arg.records {
+		size += int(rec.Length) * 2
+	}
+	data := arg.alloc(size)
+	for i := range arg.records {
+		rec := &arg.records[i]
+		rec.Data, data = data[:rec.Length*2], data[rec.Length*2:]
+		if !access.FileStore(rec.File, rec.Record, rec.Data, true, access.UserData) {
+			result.SetExcepCode(ExcepIllDataAddr)
+			break
+		}
+	}
+	return 2 + int(number)
+}
+
+// <----------------------------------- MODBUS Read File Record Response PDU ------------------------------------>
+// +-------------------+--------------------+--------------------+--------------------+--------------------+
+// | Function Code     | Resp. Data Length  | File Resp. Length  | Reference Type     | Record Data        |
+// | 1 Byte            | 1 Byte             | 1 Byte             | 1 Byte             | n*2 Bytes          |
+// +-------------------+--------------------+--------------------+--------------------+--------------------+
+
+// 封装响应报文-读文件记录
+func packResponseReadFile(result *groResult, arg *groArg, box *groBox) int {
+	records := arg.GetFileRecords()
+	length := fileReadRspLen(records)
+
+	// 检查参数
+	if len(records) < 1 || length > maxPduLen {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+	for _, rec := range records {
+		if len(rec.Data) < int(rec.Length)*2 {
+			result.SetResult(ErrResultRegValue)
+			return -1
+		}
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeReadFile)
+	box.PutU8(uint8(length - 2))
+	for _, rec := range records {
+		box.PutU8(uint8(1 + rec.Length*2))
+		box.PutU8(fileRefType)
+		box.PutU8s(rec.Data[:rec.Length*2])
+	}
+	return length
+}
+
+// 解析响应报文-读文件记录
+func parseResponseReadFile(result *groResult, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 2 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+	number := uint16(box.GetU8(1))
+	if box.ThisSize() < 2+number {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	// 检查各个子响应与子请求是否一致
+	offset := uint16(2)
+	for i := range arg.records {
+		rec := &arg.records[i]
+		if offset+2 > 2+number || uint16(box.GetU8(offset)) != 1+rec.Length*2 {
+			result.SetResult(ErrResultLength)
+			return -1
+		}
+		if box.GetU8(offset+1) != fileRefType {
+			result.SetResult(ErrResultRegValue)
+			return -1
+		}
+		if offset+2+rec.Length*2 > 2+number {
+			result.SetResult(ErrResultLength)
+			return -1
+		}
+		rec.Data = box.GetThisBuffer(offset+2, offset+2+rec.Length*2)
+		offset += 2 + rec.Length*2
+	}
+	if offset != 2+number {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	return 2 + int(number)
+}
+
+// <------------------------------------- MODBUS Write File Record Request PDU ------------------------------------->
+// +---------------+---------------+---------------+---------------+---------------+---------------+---------------+
+// | Function Code | Request Length| Reference Type| File Number   | Record Number | Record Length | Record Data   |
+// | 1 Byte        | 1 Byte        | 1 Byte        | 2 Bytes       | 2 Bytes       | 2 Bytes       | n*2 Bytes     |
+// +---------------+---------------+---------------+---------------+---------------+---------------+---------------+
+
+// 封装请求报文-写文件记录
+func packRequestWriteFile(result *groResult, arg *groArg, box *groBox) int {
+	return packFileRecords(result, arg, box, FuncCodeWriteFile)
+}
+
+// 解析请求报文-写文件记录
+// 仅检查子请求, 记录数据在校验整个报文后由 serveWriteFile 写入
+func parseRequestWriteFile(result *groResult, access *groAccess, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 2 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+	number := uint16(box.GetU8(1))
+	if box.ThisSize() < 2+number {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	// 检查参数
+	if number < 0x09 || number > 0xFB {
+		result.SetExcepCode(ExcepIllDataValue)
+		return 2 + int(number)
+	} else if access.FileStore == nil {
+		result.SetExcepCode(ExcepIllFuncCode)
+		return 2 + int(number)
+	}
+
+	arg.records = arg.records[:0]
+	for offset := uint16(2); offset < 2+number; {
+		if offset+7 > 2+number {
+			result.SetExcepCode(ExcepIllDataValue)
+			return 2 + int(number)
+		}
+		rec := FileRecord{
+			File:   box.GetU16(offset+1, binary.BigEndian),
+			Record: box.GetU16(offset+3, binary.BigEndian),
+			Length: box.GetU16(offset+5, binary.BigEndian),
+		}
+		if box.GetU8(offset) != fileRefType || rec.Length < 0x0001 || !checkFileRecord(rec.Record, rec.Length) {
+			result.SetExcepCode(ExcepIllDataAddr)
+			return 2 + int(number)
+		}
+		if offset+7+rec.Length*2 > 2+number {
+			result.SetExcepCode(ExcepIllDataValue)
+			return 2 + int(number)
+		}
+		rec.Data = box.GetThisBuffer(offset+7, offset+7+rec.Length*2)
+		arg.records = append(arg.records, rec)
+		offset += 7 + rec.Length*2
+	}
+	return 2 + int(number)
+}
+
+// 写入文件记录 (从站)
+// 在校验整个报文 (CRC/LRC) 后调用, 避免写入损坏的数据; 通过 access.FileStore 依次写入记录数据
+func (m *Modbus) serveWriteFile() {
+	if m.Arg.GetFuncCode() != FuncCodeWriteFile || m.Result.GetExcepCode() != ExcepNormal {
+		return
+	}
+	access := &m.Access
+	for _, rec := range m.Arg.records {
+		if !access.FileStore(rec.File, rec.Record, rec.Data, false, access.UserData) {
+			m.Result.SetExcepCode(ExcepIllDataAddr)
+			return
+		}
+	}
+}
+
+// <------------------ MODBUS Write File Record Response PDU ------------------->
+// +-------------------+--------------------+----------------------------------+
+// | Function Code     | Response Length    | Sub-Responses (echo of request)  |
+// | 1 Byte            | 1 Byte             | n Bytes                          |
+// +-------------------+--------------------+----------------------------------+
+
+// 封装响应报文-写文件记录
+func packResponseWriteFile(result *groResult, arg *groArg, box *groBox) int {
+	return packFileRecords(result, arg, box, FuncCodeWriteFile)
+}
+
+// 解析响应报文-写文件记录
+func parseResponseWriteFile(result *groResult, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 2 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+	number := uint16(box.GetU8(1))
+	if box.ThisSize() < 2+number {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	// 检查响应是否与请求一致
+	offset := uint16(2)
+	for _, rec := range arg.records {
+		if offset+7+rec.Length*2 > 2+number || len(rec.Data) < int(rec.Length)*2 {
+			result.SetResult(ErrResultLength)
+			return -1
+		}
+		if box.GetU8(offset) != fileRefType ||
+			box.GetU16(offset+1, binary.BigEndian) != rec.File ||
+			box.GetU16(offset+3, binary.BigEndian) != rec.Record ||
+			box.GetU16(offset+5, binary.BigEndian) != rec.Length ||
+			!bytes.Equal(box.GetThisBuffer(offset+7, offset+7+rec.Length*2), rec.Data[:rec.Length*2]) {
+			result.SetResult(ErrResultRegValue)
+			return -1
+		}
+		offset += 7 + rec.Length*2
+	}
+	if offset != 2+number {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	return 2 + int(number)
+}
+
+// 封装写文件记录的子请求 (请求与响应格式相同)
+func packFileRecords(result *groResult, arg *groArg, box *groBox, funccode uint8) int {
+	records := arg.GetFileRecords()
+
+	// 检查参数
+	if len(records) < 1 {
+		result.SetResult(ErrResultRegLen)
+		return -1
+	}
+	number := 0 // 字节数
+	for _, rec := range records {
+		if rec.Length < 0x0001 {
+			result.SetResult(ErrResultRegLen)
+			return -1
+		} else if !checkFileRecord(rec.Record, rec.Length) {
+			result.SetResult(ErrResultRegAddr)
+			return -1
+		} else if len(rec.Data) < int(rec.Length)*2 {
+			result.SetResult(ErrResultRegValue)
+			return -1
+		}
+		number += 7 + int(rec.Length)*2
+	}
+	if 2+number > maxPduLen {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	// 填充报文
+	box.PutU8(funccode)
+	box.PutU8(uint8(number))
+	for _, rec := range records {
+		box.PutU8(fileRefType)
+		box.PutU16(rec.File, binary.BigEndian)
+		box.PutU16(rec.Record, binary.BigEndian)
+		box.PutU16(rec.Length, binary.BigEndian)
+		box.PutU8s(rec.Data[:rec.Length*2])
+	}
+	return 2 + number
+}
+
+// 读文件记录响应 PDU 长度
+func fileReadRspLen(records []FileRecord) int {
+	length := 2
+	for _, rec := range records {
+		length += 2 + int(rec.Length)*2
+	}
+	return length
+}
+
+// 检查记录号范围
+func checkFileRecord(record, length uint16) bool {
+	return int(record)+int(length) <= maxFileRecord+1
+}
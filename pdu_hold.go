@@ -9,6 +9,24 @@ import (
 	"encoding/binary"
 )
 
+const (
+	maxReadHoldBytes   = 0x007D * 2 // 读保持寄存器的最大字节数
+	maxWriteHoldsBytes = 0x007B * 2 // 写多个保持寄存器的最大字节数
+)
+
+// 计算寄存器值的字节数, 并检查寄存器数量 (Enron Modbus 寄存器可为 4 字节)
+// 返回值: 数量为 0、字节数超过 max 或寄存器跨越不同宽度的范围时返回 false
+func holdBytes(arg *groArg, regaddr, reglen uint16, max int) (uint16, bool) {
+	if reglen < 0x0001 {
+		return 0, false
+	}
+	width, ok := arg.regWidth(regaddr, reglen)
+	if !ok || int(reglen)*int(width) > max {
+		return 0, false
+	}
+	return reglen * width, true
+}
+
 // <--------- MODBUS Read Holding Registers Request PDU ------------->
 // +-------------------+---------------------+-----------------------+
 // | Function Code     | Starting Address    | Quantity of Registers |
@@ -21,7 +39,7 @@ func packRequestReadHold(result *groResult, arg *groArg, box *groBox) int {
 	reglen := arg.GetRegLen()
 
 	// 检查参数
-	if reglen < 0x0001 || reglen > 0x007D {
+	if _, ok := holdBytes(arg, regaddr, reglen, maxReadHoldBytes); !ok {
 		result.SetResult(ErrResultRegLen)
 		return -1
 	}
@@ -45,11 +63,11 @@ func parseRequestReadHold(result *groResult, access *groAccess, arg *groArg, box
 	reglen := box.GetU16(3, binary.BigEndian)
 
 	// 检查参数
-	if reglen < 0x0001 || reglen > 0x007D {
+	if _, ok := holdBytes(arg, regaddr, reglen, maxReadHoldBytes); !ok {
 		result.SetExcepCode(ExcepIllDataValue)
 	} else if access.CheckHold == nil {
 		result.SetExcepCode(ExcepIllFuncCode)
-	} else if !access.CheckHold(regaddr, reglen, true, access.UserData) {
+	} else if !access.check(TableHold, regaddr, reglen, true) {
 		result.SetExcepCode(ExcepIllDataAddr)
 	} else {
 		arg.SetRegAddr(regaddr)
@@ -66,11 +84,9 @@ func parseRequestReadHold(result *groResult, access *groAccess, arg *groArg, box
 
 // 封装响应报文-读取保持寄存器
 func packResponseReadHold(result *groResult, arg *groArg, box *groBox) int {
-	reglen := arg.GetRegLen() // 寄存器数量
-	number := reglen * 2      // 字节数
-
 	// 检查参数
-	if reglen < 0x0001 || reglen > 0x007D {
+	number, ok := holdBytes(arg, arg.GetRegAddr(), arg.GetRegLen(), maxReadHoldBytes) // 字节数
+	if !ok {
 		result.SetResult(ErrResultRegLen)
 		return -1
 	}
@@ -97,7 +113,7 @@ func parseResponseReadHold(result *groResult, arg *groArg, box *groBox) int {
 		result.SetResult(ErrResultTooShort)
 		return -1
 	}
-	if number != arg.GetRegLen()*2 {
+	if expect, _ := holdBytes(arg, arg.GetRegAddr(), arg.GetRegLen(), maxReadHoldBytes); number != expect {
 		result.SetResult(ErrResultLength)
 		return -1
 	}
@@ -138,7 +154,7 @@ func parseRequestWriteHold(result *groResult, access *groAccess, arg *groArg, bo
 	// 检查参数
 	if access.CheckHold == nil {
 		result.SetExcepCode(ExcepIllFuncCode)
-	} else if !access.CheckHold(regaddr, 1, false, access.UserData) {
+	} else if !access.check(TableHold, regaddr, 1, false) {
 		result.SetExcepCode(ExcepIllDataAddr)
 	} else {
 		arg.SetRegAddr(regaddr)
@@ -185,7 +201,7 @@ func parseResponseWriteHold(result *groResult, arg *groArg, box *groBox) int {
 
 	// 检查寄存器值
 	value := box.GetU16(3, binary.BigEndian)
-	if arg.GetU16(0, binary.BigEndian) != value {
+	if len(arg.GetU8s()) < 2 || arg.GetU16(0, binary.BigEndian) != value {
 		result.SetResult(ErrResultRegValue)
 		return -1
 	}
@@ -203,9 +219,9 @@ func parseResponseWriteHold(result *groResult, arg *groArg, box *groBox) int {
 func packRequestWriteHolds(result *groResult, arg *groArg, box *groBox) int {
 	regaddr := arg.GetRegAddr() // 寄存器地址
 	reglen := arg.GetRegLen()   // 寄存器数量
-	number := reglen * 2        // 字节数
 
-	if reglen < 0x0001 || reglen > 0x007B {
+	number, ok := holdBytes(arg, regaddr, reglen, maxWriteHoldsBytes) // 字节数
+	if !ok {
 		result.SetResult(ErrResultRegLen)
 		return -1
 	}
@@ -237,11 +253,11 @@ func parseRequestWriteHolds(result *groResult, access *groAccess, arg *groArg, b
 		return -1
 	}
 
-	if reglen < 0x0001 || reglen > 0x007B || number != reglen*2 {
+	if expect, ok := holdBytes(arg, regaddr, reglen, maxWriteHoldsBytes); !ok || number != expect {
 		result.SetExcepCode(ExcepIllDataValue)
 	} else if access.CheckHold == nil {
 		result.SetExcepCode(ExcepIllFuncCode)
-	} else if !access.CheckHold(regaddr, reglen, false, access.UserData) {
+	} else if !access.check(TableHold, regaddr, reglen, false) {
 		result.SetExcepCode(ExcepIllDataAddr)
 	} else {
 		arg.SetRegAddr(regaddr)
@@ -299,3 +315,211 @@ func parseResponseWriteHolds(result *groResult, arg *groArg, box *groBox) int {
 
 	return 5
 }
+
+// <---------------------- MODBUS Mask Write Register Request PDU ----------------------->
+// +-------------------+--------------------+--------------------+--------------------+
+// | Function Code     | Reference Address  | And_Mask           | Or_Mask            |
+// | 1 Byte            | 2 Bytes            | 2 Bytes            | 2 Bytes            |
+// +-------------------+--------------------+--------------------+--------------------+
+
+// 封装请求报文-屏蔽写保持寄存器
+func packRequestMaskWriteHold(result *groResult, arg *groArg, box *groBox) int {
+	_ = result
+
+	// 填充报文
+	box.PutU8(FuncCodeMaskWriteHold)
+	box.PutU16(arg.GetRegAddr(), binary.BigEndian)
+	box.PutU16(arg.GetAndMask(), binary.BigEndian)
+	box.PutU16(arg.GetOrMask(), binary.BigEndian)
+	return 7
+}
+
+// 解析请求报文-屏蔽写保持寄存器
+// 从机通过 ApplyMask 计算寄存器的新值并写入
+func parseRequestMaskWriteHold(result *groResult, access *groAccess, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 7 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	regaddr := box.GetU16(1, binary.BigEndian) // 寄存器地址
+
+	// 检查参数
+	if access.CheckHold == nil {
+		result.SetExcepCode(ExcepIllFuncCode)
+	} else if !access.check(TableHold, regaddr, 1, false) {
+		result.SetExcepCode(ExcepIllDataAddr)
+	} else {
+		arg.SetRegAddr(regaddr)
+		arg.SetRegLen(1)
+		arg.SetMask(box.GetU16(3, binary.BigEndian), box.GetU16(5, binary.BigEndian))
+	}
+
+	return 7
+}
+
+// <---------------------- MODBUS Mask Write Register Response PDU ---------------------->
+// +-------------------+--------------------+--------------------+--------------------+
+// | Function Code     | Reference Address  | And_Mask           | Or_Mask            |
+// | 1 Byte            | 2 Bytes            | 2 Bytes            | 2 Bytes            |
+// +-------------------+--------------------+--------------------+--------------------+
+
+// 封装响应报文-屏蔽写保持寄存器
+func packResponseMaskWriteHold(result *groResult, arg *groArg, box *groBox) int {
+	return packRequestMaskWriteHold(result, arg, box)
+}
+
+// 解析响应报文-屏蔽写保持寄存器
+func parseResponseMaskWriteHold(result *groResult, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 7 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	// 检查寄存器地址
+	regaddr := box.GetU16(1, binary.BigEndian)
+	if arg.GetRegAddr() != regaddr {
+		result.SetResult(ErrResultRegAddr)
+		return -1
+	}
+
+	// 检查屏蔽码
+	andmask := box.GetU16(3, binary.BigEndian)
+	ormask := box.GetU16(5, binary.BigEndian)
+	if arg.GetAndMask() != andmask || arg.GetOrMask() != ormask {
+		result.SetResult(ErrResultRegValue)
+		return -1
+	}
+
+	return 7
+}
+
+// <------------------------------------------ MODBUS Read/Write Multiple Registers Request PDU ---------------------------------------->
+// +---------------+---------------+---------------+---------------+---------------+---------------+------------------------+
+// | Function Code | Read Address  | Read Quantity | Write Address | Write Quantity| Byte Count    | Write Registers Value  |
+// | 1 Byte        | 2 Bytes       | 2 Bytes       | 2 Bytes       | 2 Bytes       | 1 Byte        | n Bytes                |
+// +---------------+---------------+---------------+---------------+---------------+---------------+------------------------+
+
+// 封装请求报文-读写多个保持寄存器
+// 读寄存器使用 RegAddr/RegLen, 写寄存器使用 WriteRegAddr/WriteRegLen, 寄存器值为写入的值
+func packRequestReadWriteHolds(result *groResult, arg *groArg, box *groBox) int {
+	reglen := arg.GetRegLen()       // 读寄存器数量
+	wreglen := arg.GetWriteRegLen() // 写寄存器数量
+	number := wreglen * 2           // 字节数
+
+	// 检查参数
+	if reglen < 0x0001 || reglen > 0x007D || wreglen < 0x0001 || wreglen > 0x0079 {
+		result.SetResult(ErrResultRegLen)
+		return -1
+	}
+	if len(arg.GetU8s()) < int(number) {
+		result.SetResult(ErrResultRegValue)
+		return -1
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeReadWriteHolds)
+	box.PutU16(arg.GetRegAddr(), binary.BigEndian)
+	box.PutU16(reglen, binary.BigEndian)
+	box.PutU16(arg.GetWriteRegAddr(), binary.BigEndian)
+	box.PutU16(wreglen, binary.BigEndian)
+	box.PutU8(uint8(number))
+	box.PutU8s(arg.GetU8s()[0:number])
+
+	return 10 + int(number)
+}
+
+// 解析请求报文-读写多个保持寄存器
+// 寄存器值为写入的值, 从机需先写入, 再将读取的值通过 SetU16s 等设置后封装响应
+func parseRequestReadWriteHolds(result *groResult, access *groAccess, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 10 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	regaddr := box.GetU16(1, binary.BigEndian)
+	reglen := box.GetU16(3, binary.BigEndian)
+	wregaddr := box.GetU16(5, binary.BigEndian)
+	wreglen := box.GetU16(7, binary.BigEndian)
+	number := uint16(box.GetU8(9))
+
+	// 校验报文长度
+	if box.ThisSize() < 10+number {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	// 检查参数
+	if reglen < 0x0001 || reglen > 0x007D || wreglen < 0x0001 || wreglen > 0x0079 || number != wreglen*2 {
+		result.SetExcepCode(ExcepIllDataValue)
+	} else if access.CheckHold == nil {
+		result.SetExcepCode(ExcepIllFuncCode)
+	} else if !access.check(TableHold, regaddr, reglen, true) ||
+		!access.check(TableHold, wregaddr, wreglen, false) {
+		result.SetExcepCode(ExcepIllDataAddr)
+	} else {
+		arg.SetRegAddr(regaddr)
+		arg.SetRegLen(reglen)
+		arg.SetWriteRegAddr(wregaddr)
+		arg.SetWriteRegLen(wreglen)
+		arg.SetU8s(box.GetThisBuffer(10, 10+number))
+	}
+
+	return 10 + int(number)
+}
+
+// <------ MODBUS Read/Write Multiple Registers Response PDU ---->
+// +-------------------+--------------------+--------------------+
+// | Function Code     | Byte Count         | Read Registers     |
+// | 1 Byte            | 1 Byte             | n Bytes            |
+// +-------------------+--------------------+--------------------+
+
+// 封装响应报文-读写多个保持寄存器
+func packResponseReadWriteHolds(result *groResult, arg *groArg, box *groBox) int {
+	reglen := arg.GetRegLen() // 读寄存器数量
+	number := reglen * 2      // 字节数
+
+	// 检查参数
+	if reglen < 0x0001 || reglen > 0x007D {
+		result.SetResult(ErrResultRegLen)
+		return -1
+	}
+	if len(arg.GetU8s()) < int(number) {
+		result.SetResult(ErrResultRegValue)
+		return -1
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeReadWriteHolds)
+	box.PutU8(uint8(number))
+	box.PutU8s(arg.GetU8s()[0:number])
+
+	return 2 + int(number)
+}
+
+// 解析响应报文-读写多个保持寄存器
+func parseResponseReadWriteHolds(result *groResult, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 2 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	// 检查字节数/寄存器数量是否错误
+	number := uint16(box.GetU8(1))
+	if box.ThisSize() < 2+number {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+	if number != arg.GetRegLen()*2 {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	arg.SetU8s(box.GetThisBuffer(2, 2+number))
+
+	return 2 + int(number)
+}
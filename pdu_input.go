@@ -49,7 +49,7 @@ func parseRequestReadInput(result *groResult, access *groAccess, arg *groArg, bo
 		result.SetExcepCode(ExcepIllDataValue)
 	} else if access.CheckInput == nil {
 		result.SetExcepCode(ExcepIllFuncCode)
-	} else if !access.CheckInput(regaddr, reglen, true, access.UserData) {
+	} else if !access.check(TableInput, regaddr, reglen, true) {
 		result.SetExcepCode(ExcepIllDataAddr)
 	} else {
 		arg.SetRegAddr(regaddr)
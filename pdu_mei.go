@@ -0,0 +1,250 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+// <------------- MODBUS Read Device Identification Request PDU -------------->
+// +-------------------+-------------------+-------------------+-------------------+
+// | Function Code     | MEI Type          | Read Dev Id Code  | Object Id         |
+// | 1 Byte            | 1 Byte            | 1 Byte            | 1 Byte            |
+// +-------------------+-------------------+-------------------+-------------------+
+
+const (
+	devIdentHeadLen  = 7 // 读设备标识响应 PDU 头部长度
+	devIdentMaxBasic = 0x02
+	devIdentMaxReg   = 0x7F
+	devIdentMaxExt   = 0xFF
+)
+
+// 封装请求报文-读设备标识
+func packRequestReadDevIdent(result *groResult, arg *groArg, box *groBox) int {
+	code := arg.GetDevIdCode()
+
+	// 检查参数
+	if code < DevIdBasic || code > DevIdSpecific {
+		result.SetResult(ErrResultRegValue)
+		return -1
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeMei)
+	box.PutU8(MeiReadDevIdent)
+	box.PutU8(code)
+	box.PutU8(arg.GetObjectId())
+	return 4
+}
+
+// 解析请求报文-读设备标识
+// 依据 access.DevIdent 收集对象, 超出 PDU 长度的对象通过 "后续对象" 分页返回
+func parseRequestReadDevIdent(result *groResult, access *groAccess, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < 4 {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	code := box.GetU8(2)
+	objid := box.GetU8(3)
+
+	// 检查参数
+	if box.GetU8(1) != MeiReadDevIdent {
+		result.SetExcepCode(ExcepIllFuncCode)
+		return 4
+	} else if code < DevIdBasic || code > DevIdSpecific {
+		result.SetExcepCode(ExcepIllDataValue)
+		return 4
+	} else if access.DevIdent == nil {
+		result.SetExcepCode(ExcepIllFuncCode)
+		return 4
+	}
+
+	ident := &arg.ident
+	ident.code = code
+	ident.objid = objid
+	ident.conformity = devIdentConformity(access)
+	ident.more = false
+	ident.nextobjid = 0
+	ident.objects = ident.objects[:0]
+
+	// 单独访问
+	if code == DevIdSpecific {
+		value, ok := access.DevIdent(objid, access.UserData)
+		if !ok {
+			result.SetExcepCode(ExcepIllDataAddr)
+			return 4
+		}
+		if len(value) > maxPduLen-devIdentHeadLen-2 {
+			value = value[:maxPduLen-devIdentHeadLen-2]
+		}
+		ident.objects = append(ident.objects, DevIdObject{Id: objid, Value: value})
+		return 4
+	}
+
+	// 流访问: 对象ID不存在时从头开始
+	last := devIdentLast(code)
+	if _, ok := access.DevIdent(objid, access.UserData); !ok || objid > last {
+		objid = 0
+	}
+
+	used := devIdentHeadLen
+	for id := int(objid); id <= int(last); id++ {
+		value, ok := access.DevIdent(uint8(id), access.UserData)
+		if !ok {
+			continue
+		}
+		if used+2+len(value) > maxPduLen {
+			if len(ident.objects) > 0 {
+				ident.more = true
+				ident.nextobjid = uint8(id)
+				break
+			}
+			// 单个对象超出 PDU 长度时截断
+			value = value[:maxPduLen-used-2]
+		}
+		ident.objects = append(ident.objects, DevIdObject{Id: uint8(id), Value: value})
+		used += 2 + len(value)
+	}
+	return 4
+}
+
+// <----------------------------------- MODBUS Read Device Identification Response PDU ------------------------------------>
+// +---------------+---------------+---------------+---------------+---------------+---------------+---------------+----------------+
+// | Function Code | MEI Type      | Read Dev Id   | Conformity    | More Follows  | Next Object Id| Number of Obj | Object List    |
+// | 1 Byte        | 1 Byte        | 1 Byte        | 1 Byte        | 1 Byte        | 1 Byte        | 1 Byte        | (Id, Len, Val) |
+// +---------------+---------------+---------------+---------------+---------------+---------------+---------------+----------------+
+
+// 封装响应报文-读设备标识
+func packResponseReadDevIdent(result *groResult, arg *groArg, box *groBox) int {
+	ident := &arg.ident
+
+	// 检查参数
+	length := devIdentHeadLen
+	for _, obj := range ident.objects {
+		length += 2 + len(obj.Value)
+	}
+	if length > maxPduLen || len(ident.objects) > 0xFF {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	// 填充报文
+	box.PutU8(FuncCodeMei)
+	box.PutU8(MeiReadDevIdent)
+	box.PutU8(ident.code)
+	box.PutU8(ident.conformity)
+	if ident.more {
+		box.PutU8(0xFF)
+	} else {
+		box.PutU8(0x00)
+	}
+	box.PutU8(ident.nextobjid)
+	box.PutU8(uint8(len(ident.objects)))
+	for _, obj := range ident.objects {
+		box.PutU8(obj.Id)
+		box.PutU8(uint8(len(obj.Value)))
+		box.PutU8s(obj.Value)
+	}
+
+	return length
+}
+
+// 解析响应报文-读设备标识
+func parseResponseReadDevIdent(result *groResult, arg *groArg, box *groBox) int {
+	// 检查报文是否过短
+	if box.ThisSize() < devIdentHeadLen {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+
+	// 检查封装接口类型与读设备标识码
+	if box.GetU8(1) != MeiReadDevIdent {
+		result.SetResult(ErrResultFuncCode)
+		return -1
+	}
+	if box.GetU8(2) != arg.GetDevIdCode() {
+		result.SetResult(ErrResultRegValue)
+		return -1
+	}
+
+	ident := &arg.ident
+	ident.conformity = box.GetU8(3)
+	ident.more = box.GetU8(4) == 0xFF
+	ident.nextobjid = box.GetU8(5)
+	ident.objects = ident.objects[:0]
+
+	number := int(box.GetU8(6))
+	offset := uint16(devIdentHeadLen)
+	for i := 0; i < number; i++ {
+		if box.ThisSize() < offset+2 {
+			result.SetResult(ErrResultTooShort)
+			return -1
+		}
+		id := box.GetU8(offset)
+		size := uint16(box.GetU8(offset + 1))
+		if box.ThisSize() < offset+2+size {
+			result.SetResult(ErrResultTooShort)
+			return -1
+		}
+		ident.objects = append(ident.objects, DevIdObject{Id: id, Value: box.GetThisBuffer(offset+2, offset+2+size)})
+		offset += 2 + size
+	}
+
+	return int(offset)
+}
+
+// 流访问类别的最后一个对象ID
+func devIdentLast(code uint8) uint8 {
+	switch code {
+	case DevIdBasic:
+		return devIdentMaxBasic
+	case DevIdRegular:
+		return devIdentMaxReg
+	default:
+		return devIdentMaxExt
+	}
+}
+
+// 一致性等级: 依据存在的对象判断支持的类别, 并支持单独访问 (0x80)
+func devIdentConformity(access *groAccess) uint8 {
+	for id := int(devIdentMaxExt); id > devIdentMaxBasic; id-- {
+		if _, ok := access.DevIdent(uint8(id), access.UserData); ok {
+			if id > devIdentMaxReg {
+				return 0x80 | DevIdExtended
+			}
+			return 0x80 | DevIdRegular
+		}
+	}
+	return 0x80 | DevIdBasic
+}
+
+// 读设备标识 (主站)
+// 按 "后续对象" 分页发送请求, 直至读取全部对象; 单独访问时只发送一次请求
+// 返回的对象值为复制后的数据
+func (m *Modbus) ReadDevIdent(code, objid uint8, transact Transact) ([]DevIdObject, error) {
+	var objects []DevIdObject
+
+	m.Arg.SetFuncCode(FuncCodeMei)
+	for {
+		m.Arg.SetDevIdent(code, objid)
+
+		if err := m.transact(transact); err != nil {
+			return nil, err
+		}
+
+		for _, obj := range m.Arg.GetObjects() {
+			objects = append(objects, DevIdObject{Id: obj.Id, Value: append([]uint8(nil), obj.Value...)})
+		}
+
+		more, next := m.Arg.GetMoreFollows()
+		if !more || code == DevIdSpecific {
+			return objects, nil
+		}
+		// 下一个对象ID必须递增, 避免无限循环
+		if next <= objid {
+			return nil, ErrResultRegValue
+		}
+		objid = next
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+// 封装报文-原始 PDU
+func packRaw(result *groResult, arg *groArg, box *groBox) int {
+	pdu := arg.GetRawPdu()
+
+	// 检查参数
+	if len(pdu) < 1 || len(pdu) > maxPduLen {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	// 填充报文
+	arg.SetFuncCode(pdu[0])
+	box.PutU8s(pdu)
+	return len(pdu)
+}
+
+// 解析报文-原始 PDU
+// length 为依据 ADU 帧计算的 PDU 长度, 响应为异常响应时保存异常码
+func parseRaw(result *groResult, arg *groArg, box *groBox, length int, isReq bool) int {
+	// 检查报文长度
+	if length < 1 || length > int(box.ThisSize()) {
+		result.SetResult(ErrResultTooShort)
+		return -1
+	}
+	if length > maxPduLen {
+		result.SetResult(ErrResultLength)
+		return -1
+	}
+
+	pdu := box.GetThisBuffer(0, uint16(length))
+	arg.SetFuncCode(pdu[0])
+	arg.SetRawPdu(pdu)
+
+	// 检查是否为异常响应
+	if !isReq && pdu[0]&0x80 != 0 {
+		if length < 2 {
+			result.SetResult(ErrResultTooShort)
+			return -1
+		}
+		result.SetExcepCode(pdu[1])
+	}
+	return length
+}
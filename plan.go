@@ -0,0 +1,178 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"sort"
+)
+
+// 地址范围 (数据表中的 [Addr, Addr+Len), 位数据表为位数, 其他为寄存器数量)
+type Span struct {
+	Table Table  // 数据表
+	Addr  uint16 // 起始地址
+	Len   uint16 // 数量
+}
+
+func (s Span) end() int {
+	return int(s.Addr) + int(s.Len)
+}
+
+// 是否与 o 重叠
+func (s Span) overlaps(o Span) bool {
+	return s.Table == o.Table && int(s.Addr) < o.end() && int(o.Addr) < s.end()
+}
+
+// 读请求计划
+// 将点位合并为尽可能少的读请求 (读线圈/读离散量输入/读保持寄存器/读输入寄存器)
+type ReadPlanner struct {
+	MaxGap  uint16 // 允许一并读取的最大空隙 (点位之间未使用的位或寄存器数量)
+	MaxRegs uint16 // 单个请求的最大寄存器数量 (0 或超过 0x7D 时为 0x7D)
+	MaxBits uint16 // 单个请求的最大位数 (0 或超过 0x7D0 时为 0x7D0)
+	Holes   []Span // 禁止读取的地址范围 (如读取时设备响应非法数据地址的范围)
+}
+
+// 单个请求的最大数量
+func (p *ReadPlanner) maxLen(t Table) int {
+	n := int(p.MaxRegs)
+	if t.isBit() {
+		n = int(p.MaxBits)
+	}
+	if n == 0 || n > t.maxRead() {
+		n = t.maxRead()
+	}
+	return n
+}
+
+// 范围是否与禁止读取的地址范围重叠
+func (p *ReadPlanner) inHole(s Span) bool {
+	for _, h := range p.Holes {
+		if h.overlaps(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// 读请求
+type ReadRequest struct {
+	Span         // 读取的地址范围
+	Points []int // 包含的点位 (Plan 参数中的序号, 按地址排序)
+}
+
+// 读请求的功能码
+func (r *ReadRequest) FuncCode() uint8 {
+	switch r.Table {
+	case TableCoil:
+		return FuncCodeReadCoil
+	case TableDiscrete:
+		return FuncCodeReadDiscrete
+	case TableInput:
+		return FuncCodeReadInput
+	}
+	return FuncCodeReadHold
+}
+
+// 初始化请求参数 (功能码、起始地址与数量)
+func (r *ReadRequest) Init(arg *groArg) {
+	arg.Init(r.FuncCode(), r.Addr, r.Len)
+}
+
+// 从解析的响应报文中截取 s 的值
+// 寄存器为 s.Len*2 字节 (与 arg 共享内存); 位数据表重新按位打包, 第 0 位为 s.Addr
+// 返回值: s 不在请求范围内时返回 ErrResultRegAddr, 寄存器值过短时返回 ErrResultLength
+func (r *ReadRequest) Slice(arg *groArg, s Span) ([]uint8, error) {
+	if s.Table != r.Table || s.Addr < r.Addr || s.end() > r.end() {
+		return nil, ErrResultRegAddr
+	}
+	off := int(s.Addr - r.Addr)
+	if !r.Table.isBit() {
+		if len(arg.all) < (off+int(s.Len))*2 {
+			return nil, ErrResultLength
+		}
+		return arg.all[off*2 : (off+int(s.Len))*2], nil
+	}
+
+	if len(arg.all) < (off+int(s.Len)+7)/8 {
+		return nil, ErrResultLength
+	}
+	bits := make([]uint8, (s.Len+7)/8)
+	for i := 0; i < int(s.Len); i++ {
+		if n := off + i; arg.all[n/8]&(1<<(n%8)) != 0 {
+			bits[i/8] |= 1 << (i % 8)
+		}
+	}
+	return bits, nil
+}
+
+// 生成读请求计划
+// 同一数据表的点位按地址排序后依次合并: 合并后的范围不超过单个请求的最大数量、与前一点位的空隙不超过 MaxGap
+// 且不包含禁止读取的地址范围; 重叠的点位可以合并到同一请求中
+// 返回值: 点位数量为 0、超过单个请求的最大数量时返回 ErrResultRegLen, 超出 0xFFFF 或包含禁止读取的地址时返回 ErrResultRegAddr
+func (p *ReadPlanner) Plan(points []Span) ([]ReadRequest, error) {
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+		s := points[i]
+		switch {
+		case s.Len == 0 || int(s.Len) > p.maxLen(s.Table):
+			return nil, ErrResultRegLen
+		case s.end() > 0x10000 || p.inHole(s) || s.Table < TableCoil || s.Table > TableHold:
+			return nil, ErrResultRegAddr
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := points[order[i]], points[order[j]]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		return a.Addr < b.Addr
+	})
+
+	var reqs []ReadRequest
+	for _, i := range order {
+		s := points[i]
+		if n := len(reqs); n > 0 {
+			r := &reqs[n-1]
+			merged := Span{Table: r.Table, Addr: r.Addr, Len: uint16(max(r.end(), s.end()) - int(r.Addr))}
+			if r.Table == s.Table && int(s.Addr)-r.end() <= int(p.MaxGap) &&
+				int(merged.Len) <= p.maxLen(s.Table) && !p.inHole(merged) {
+				r.Span = merged
+				r.Points = append(r.Points, i)
+				continue
+			}
+		}
+		reqs = append(reqs, ReadRequest{Span: s, Points: []int{i}})
+	}
+	return reqs, nil
+}
+
+// 点位的地址范围
+func (p *Point) Span() Span {
+	return Span{Table: p.codec.table, Addr: p.codec.addr, Len: p.codec.size}
+}
+
+// 生成全部可读点位的读请求计划, 请求中的点位序号对应 Points() 的序号
+// 响应报文解析后可通过 RegMap.Decode 解码请求中的全部点位
+func (m *RegMap) Plan(p *ReadPlanner) ([]ReadRequest, error) {
+	spans := make([]Span, len(m.points))
+	index := make([]int, 0, len(m.points))
+	for i, pt := range m.points {
+		if pt.Access&AccessRead != 0 {
+			spans[len(index)] = pt.Span()
+			index = append(index, i)
+		}
+	}
+	reqs, err := p.Plan(spans[:len(index)])
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reqs {
+		for j, k := range r.Points {
+			r.Points[j] = index[k]
+		}
+	}
+	return reqs, nil
+}
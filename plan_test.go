@@ -0,0 +1,152 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadPlanner(t *testing.T) {
+	points := []Span{
+		{TableHold, 10, 2},    // 0
+		{TableHold, 0, 1},     // 1
+		{TableHold, 3, 1},     // 2: 空隙 2
+		{TableHold, 11, 1},    // 3: 与 0 重叠
+		{TableHold, 20, 4},    // 4: 空洞 16~19 之后
+		{TableCoil, 0, 0x7CF}, // 5
+		{TableCoil, 0x7CF, 1}, // 6: 合并后为 0x7D0 位
+		{TableCoil, 0x7D0, 1}, // 7: 超出 0x7D0 位
+		{TableInput, 0, 0x7D}, // 8
+		{TableInput, 0x7D, 1}, // 9: 超出 0x7D 寄存器
+	}
+	p := &ReadPlanner{MaxGap: 2, Holes: []Span{{TableHold, 16, 4}, {TableInput, 0x200, 1}}}
+	reqs, err := p.Plan(points)
+	if err != nil {
+		t.Fatalf("Failed to plan: %v.\n", err)
+	}
+	expect := []ReadRequest{
+		{Span{TableCoil, 0, 0x7D0}, []int{5, 6}},
+		{Span{TableCoil, 0x7D0, 1}, []int{7}},
+		{Span{TableInput, 0, 0x7D}, []int{8}},
+		{Span{TableInput, 0x7D, 1}, []int{9}},
+		{Span{TableHold, 0, 4}, []int{1, 2}},
+		{Span{TableHold, 10, 2}, []int{0, 3}},
+		{Span{TableHold, 20, 4}, []int{4}},
+	}
+	if !reflect.DeepEqual(reqs, expect) {
+		t.Fatalf("Plan mismatch:\nExpected = %v\nActual   = %v.\n", expect, reqs)
+	}
+
+	// 不允许空隙时分别读取; 空隙可以跨越, 但不能包含禁止读取的地址
+	p.MaxGap = 0
+	if reqs, _ := p.Plan(points[1:3]); len(reqs) != 2 {
+		t.Fatalf("Expected 2 requests without gap, got %v.\n", reqs)
+	}
+	p.MaxGap, p.MaxRegs = 100, 10
+	if reqs, _ := p.Plan([]Span{{TableHold, 0, 2}, {TableHold, 9, 1}}); len(reqs) != 1 || reqs[0].Len != 10 {
+		t.Fatalf("Expected 1 request of 10 registers, got %v.\n", reqs)
+	}
+	if reqs, _ := p.Plan([]Span{{TableHold, 0, 2}, {TableHold, 10, 1}}); len(reqs) != 2 {
+		t.Fatalf("Expected MaxRegs to split requests, got %v.\n", reqs)
+	}
+	if reqs, _ := p.Plan([]Span{{TableHold, 14, 1}, {TableHold, 20, 1}}); len(reqs) != 2 {
+		t.Fatalf("Expected hole to split requests, got %v.\n", reqs)
+	}
+
+	// 点位本身无法读取
+	for _, s := range []Span{
+		{TableHold, 0, 0},
+		{TableHold, 0, 11},
+		{TableCoil, 0, 0x7D1},
+	} {
+		if _, err := p.Plan([]Span{s}); !errors.Is(err, ErrResultRegLen) {
+			t.Fatalf("%v: expected ErrResultRegLen, got %v.\n", s, err)
+		}
+	}
+	for _, s := range []Span{
+		{TableHold, 18, 1},
+		{TableHold, 0xFFFF, 2},
+		{0, 0, 1},
+	} {
+		if _, err := p.Plan([]Span{s}); !errors.Is(err, ErrResultRegAddr) {
+			t.Fatalf("%v: expected ErrResultRegAddr, got %v.\n", s, err)
+		}
+	}
+}
+
+func TestReadRequestSlice(t *testing.T) {
+	var arg groArg
+	r := ReadRequest{Span: Span{TableHold, 100, 4}}
+	r.Init(&arg)
+	if arg.GetFuncCode() != FuncCodeReadHold || arg.GetRegAddr() != 100 || arg.GetRegLen() != 4 {
+		t.Fatalf("Unexpected request: %s %d %d.\n", FuncCodeToString(arg.GetFuncCode()), arg.GetRegAddr(), arg.GetRegLen())
+	}
+	arg.SetU8s(strToHex("0001 0002 0003 0004"))
+	if got, err := r.Slice(&arg, Span{TableHold, 101, 2}); err != nil || !bytes.Equal(got, strToHex("0002 0003")) {
+		t.Fatalf("Slice() = %x, %v.\n", got, err)
+	}
+	if _, err := r.Slice(&arg, Span{TableHold, 103, 2}); !errors.Is(err, ErrResultRegAddr) {
+		t.Fatalf("Expected ErrResultRegAddr, got %v.\n", err)
+	}
+	if _, err := r.Slice(&arg, Span{TableInput, 100, 1}); !errors.Is(err, ErrResultRegAddr) {
+		t.Fatalf("Expected ErrResultRegAddr for table, got %v.\n", err)
+	}
+	arg.SetU8s(strToHex("0001"))
+	if _, err := r.Slice(&arg, Span{TableHold, 101, 1}); !errors.Is(err, ErrResultLength) {
+		t.Fatalf("Expected ErrResultLength, got %v.\n", err)
+	}
+
+	// 位数据表按位重新打包
+	r = ReadRequest{Span: Span{TableDiscrete, 0, 20}}
+	r.Init(&arg)
+	if arg.GetFuncCode() != FuncCodeReadDiscrete {
+		t.Fatalf("Unexpected function code %s.\n", FuncCodeToString(arg.GetFuncCode()))
+	}
+	arg.SetU8s([]uint8{0xA0, 0xF5, 0x0F})
+	if got, err := r.Slice(&arg, Span{TableDiscrete, 5, 10}); err != nil || !bytes.Equal(got, []uint8{0xAD, 0x03}) {
+		t.Fatalf("Slice() = %x, %v.\n", got, err)
+	}
+}
+
+func TestRegMapPlan(t *testing.T) {
+	m, err := LoadRegMapCSV(strings.NewReader(testRegMapCSV))
+	if err != nil {
+		t.Fatalf("Failed to load CSV: %v.\n", err)
+	}
+	reqs, err := m.Plan(&ReadPlanner{})
+	if err != nil {
+		t.Fatalf("Failed to plan: %v.\n", err)
+	}
+
+	// command 只写, 不参与读取
+	expect := []ReadRequest{
+		{Span{TableCoil, 0, 1}, []int{9}},
+		{Span{TableDiscrete, 0x10, 1}, []int{10}},
+		{Span{TableInput, 0, 9}, []int{0, 1, 2, 3, 4, 5, 6}},
+		{Span{TableHold, 100, 2}, []int{7}},
+	}
+	if !reflect.DeepEqual(reqs, expect) {
+		t.Fatalf("Plan mismatch:\nExpected = %v\nActual   = %v.\n", expect, reqs)
+	}
+
+	var arg groArg
+	reqs[2].Init(&arg)
+	arg.SetU8s(strToHex("0901" + "0000 3F80" + "0001 E240" + "FFD8" + "0051" + "4142 4300"))
+	values, err := m.Decode(&arg)
+	if err != nil || len(values) != 7 || values["serial"] != "ABC" {
+		t.Fatalf("Decode mismatch: %v, %v.\n", values, err)
+	}
+	for _, i := range reqs[2].Points {
+		pt := m.Points()[i]
+		if data, err := reqs[2].Slice(&arg, pt.Span()); err != nil || len(data) != int(pt.Size())*2 {
+			t.Fatalf("%s: Slice() = %x, %v.\n", pt.Name, data, err)
+		}
+	}
+}
@@ -0,0 +1,539 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 点位访问权限 (Point Access)
+type PointAccess uint8
+
+const (
+	AccessRead  PointAccess = 1 << iota // 可读
+	AccessWrite                         // 可写
+
+	AccessReadWrite = AccessRead | AccessWrite // 可读写
+)
+
+var accessNames = map[string]PointAccess{
+	"r":  AccessRead,
+	"w":  AccessWrite,
+	"rw": AccessReadWrite,
+}
+
+func (a PointAccess) String() string {
+	switch a {
+	case AccessRead:
+		return "r"
+	case AccessWrite:
+		return "w"
+	case AccessReadWrite:
+		return "rw"
+	}
+	return "unknown access"
+}
+
+// 点位错误 (寄存器映射表的加载、解码与编码)
+// Err 为 ErrResultRegMap/ErrResultPointAccess/ErrResultFieldRange/ErrResultRegValue 等, 可通过 errors.Is 判断
+type PointError struct {
+	Line int    // 行号 (CSV 为文件中的行号, JSON 为数组中的序号, 从 1 开始; 0 表示未知)
+	Name string // 点位名称
+	Err  error  // 错误原因
+	Msg  string // 详细信息
+}
+
+func (e *PointError) Error() string {
+	s := "point " + strconv.Quote(e.Name)
+	if e.Line > 0 {
+		s += " (line " + strconv.Itoa(e.Line) + ")"
+	}
+	return s + ": " + e.Err.Error() + " (" + e.Msg + ")"
+}
+
+func (e *PointError) Unwrap() error {
+	return e.Err
+}
+
+// 点位定义 (寄存器映射表中的一行)
+// JSON 格式为对象数组, 键名与 CSV 的列名相同, 如:
+//
+//	[{"name": "voltage", "table": "input", "address": 100, "type": "uint16", "scale": 0.1, "unit": "V"}]
+type PointDef struct {
+	Name    string  `json:"name"`             // 名称 (必需, 不可重复)
+	Table   string  `json:"table,omitempty"`  // 数据表 coil/discrete/input/hold (默认 hold)
+	Address uint16  `json:"address"`          // 起始地址 (必需, CSV 支持 0x 前缀)
+	Type    string  `json:"type,omitempty"`   // 原始类型, 同结构体标签 (线圈/离散量输入默认 bool, 寄存器默认 uint16)
+	Order   string  `json:"order,omitempty"`  // 字/字节顺序 ABCD/CDAB/BADC/DCBA (默认 ABCD)
+	Scale   float64 `json:"scale,omitempty"`  // 缩放系数, 工程值 = 原始值 * scale (0 表示不缩放)
+	Unit    string  `json:"unit,omitempty"`   // 工程单位
+	Access  string  `json:"access,omitempty"` // 访问权限 r/w/rw (线圈/保持寄存器默认 rw, 其他默认 r)
+	Len     uint16  `json:"len,omitempty"`    // 字符串的寄存器数量, 或位字段的宽度
+	Bit     *uint8  `json:"bit,omitempty"`    // 寄存器中位字段的起始位 (0~15)
+	Pad     string  `json:"pad,omitempty"`    // 字符串的填充字符 nul/space (默认 nul)
+}
+
+// 点位
+type Point struct {
+	Name   string      // 名称
+	Unit   string      // 工程单位
+	Access PointAccess // 访问权限
+	codec  regCodec
+	typ    reflect.Type // 工程值类型
+}
+
+// 创建点位, 检查数据表、地址、类型与访问权限
+// 地址范围不能超出 0xFFFF, 且点位需能通过单个读请求 (可写时还需单个写请求) 访问
+// 返回值: 定义错误时返回 *PointError (ErrResultRegMap)
+func NewPoint(def PointDef) (*Point, error) {
+	p := &Point{Name: def.Name, Unit: def.Unit}
+	if msg := p.init(&def); msg != "" {
+		return nil, &PointError{Name: def.Name, Err: ErrResultRegMap, Msg: msg}
+	}
+	return p, nil
+}
+
+func (p *Point) init(def *PointDef) string {
+	c := &p.codec
+	c.addr, c.bit = def.Address, -1
+	if p.Name == "" {
+		return "missing name"
+	}
+
+	var ok bool
+	c.table = TableHold
+	if def.Table != "" {
+		if c.table, ok = tableNames[strings.ToLower(def.Table)]; !ok {
+			return "invalid table " + strconv.Quote(def.Table)
+		}
+	}
+	c.kind = kindUint16
+	if c.table.isBit() {
+		c.kind = kindBool
+	}
+	if def.Type != "" {
+		if c.kind, ok = kindNames[strings.ToLower(def.Type)]; !ok {
+			return "invalid type " + strconv.Quote(def.Type)
+		}
+	}
+	if def.Order != "" {
+		if c.order, ok = orderNames[strings.ToUpper(def.Order)]; !ok {
+			return "invalid order " + strconv.Quote(def.Order)
+		}
+	}
+	if def.Scale != 0 {
+		if math.IsInf(def.Scale, 0) || math.IsNaN(def.Scale) {
+			return "invalid scale"
+		}
+		c.scale = def.Scale
+	}
+	if def.Bit != nil {
+		if *def.Bit > 15 {
+			return "invalid bit " + strconv.Itoa(int(*def.Bit))
+		}
+		c.bit = int(*def.Bit)
+	}
+	if def.Pad != "" {
+		if c.pad, ok = padNames[strings.ToLower(def.Pad)]; !ok {
+			return "invalid pad " + strconv.Quote(def.Pad)
+		}
+	}
+	p.Access = AccessReadWrite
+	if c.table.isReadOnly() {
+		p.Access = AccessRead
+	}
+	if def.Access != "" {
+		if p.Access, ok = accessNames[strings.ToLower(def.Access)]; !ok {
+			return "invalid access " + strconv.Quote(def.Access)
+		}
+	}
+
+	// 工程值类型
+	isBool := c.kind == kindBool
+	switch {
+	case isBool:
+		p.typ = reflect.TypeOf(false)
+	case c.kind == kindString:
+		p.typ = reflect.TypeOf("")
+	case c.scale != 0 || c.kind.isFloat():
+		p.typ = reflect.TypeOf(float64(0))
+	case c.kind == kindInt16 || c.kind == kindInt32 || c.kind == kindInt64:
+		p.typ = reflect.TypeOf(int64(0))
+	default:
+		p.typ = reflect.TypeOf(uint64(0))
+	}
+	if isBool && c.scale != 0 {
+		return "bool cannot have scale"
+	}
+	if msg := c.init(int(def.Len), def.Pad != ""); msg != "" {
+		return msg
+	}
+
+	// 检查地址范围与单个请求的最大数量
+	if int(c.addr)+int(c.size) > math.MaxUint16+1 {
+		return "address exceeds 0xFFFF"
+	}
+	if int(c.size) > c.table.maxRead() {
+		return "size exceeds " + strconv.Itoa(c.table.maxRead()) + " per read request"
+	}
+	if p.Access&AccessWrite != 0 {
+		if c.table.isReadOnly() {
+			return c.table.String() + " is read-only"
+		}
+		if int(c.size) > c.table.maxWrite() {
+			return "size exceeds " + strconv.Itoa(c.table.maxWrite()) + " per write request"
+		}
+	}
+	return ""
+}
+
+// 数据表
+func (p *Point) Table() Table {
+	return p.codec.table
+}
+
+// 起始地址
+func (p *Point) Addr() uint16 {
+	return p.codec.addr
+}
+
+// 占用的数量 (寄存器数量, 位数据表为位数)
+func (p *Point) Size() uint16 {
+	return p.codec.size
+}
+
+// 原始类型名称
+func (p *Point) Type() string {
+	if p.codec.bit >= 0 {
+		if p.typ.Kind() == reflect.Bool {
+			return "bool"
+		}
+		return "uint16"
+	}
+	return p.codec.kind.String()
+}
+
+// 字/字节顺序
+func (p *Point) Order() WordOrder {
+	return p.codec.order
+}
+
+// 缩放系数 (0 表示不缩放)
+func (p *Point) Scale() float64 {
+	return p.codec.scale
+}
+
+// 检查点位是否完整位于寄存器值范围内, 返回相对于 Arg.GetRegAddr() 的偏移
+func (p *Point) offset(table Table, base, count int) (int, bool, error) {
+	if p.codec.table != table {
+		return 0, false, nil
+	}
+	start := int(p.codec.addr)
+	in, msg := checkSpan(start, start+int(p.codec.size), base, count)
+	if msg != "" {
+		return 0, false, &PointError{Name: p.Name, Err: ErrResultFieldRange, Msg: msg}
+	}
+	return start - base, in, nil
+}
+
+// 与 offset 相同, 但点位不在范围内时返回错误
+func (p *Point) mustOffset(table Table, base, count int) (int, error) {
+	off, in, err := p.offset(table, base, count)
+	if err == nil && !in {
+		err = &PointError{Name: p.Name, Err: ErrResultFieldRange, Msg: "point not within " + table.String() + " " +
+			strconv.Itoa(base) + "~" + strconv.Itoa(base+count-1)}
+	}
+	return off, err
+}
+
+// 从寄存器值 (如解析的响应报文) 中解码点位的工程值
+// 工程值类型: bool (线圈/离散量输入/寄存器中的位)、string、float64 (浮点类型或有缩放系数)、int64 (有符号整数) 或 uint64
+// 数据表依据 Arg 的功能码确定, 点位需完整位于 [GetRegAddr, GetRegAddr+GetRegLen) 范围内
+// 返回值: 点位不可读、不在范围内或寄存器值无效时返回错误
+func (p *Point) Decode(arg *groArg) (any, error) {
+	if p.Access&AccessRead == 0 {
+		return nil, &PointError{Name: p.Name, Err: ErrResultPointAccess, Msg: "point is write-only"}
+	}
+	table, base, count, err := argSpan(arg, false)
+	if err != nil {
+		return nil, err
+	}
+	off, err := p.mustOffset(table, base, count)
+	if err != nil {
+		return nil, err
+	}
+	return p.get(arg, off)
+}
+
+// 将点位的工程值编码为寄存器值 (如写多个保持寄存器的请求)
+// value 的类型需与工程值类型相容: bool 点位为 bool, 字符串点位为 string, 其他为整数或浮点数
+// 调用前需通过 Arg.Init 设置功能码、起始地址与数量, 未被点位覆盖的寄存器值保持不变
+// 返回值: 点位不可写、不在范围内或值超出原始类型范围时返回错误
+func (p *Point) Encode(arg *groArg, value any) error {
+	if p.Access&AccessWrite == 0 {
+		return &PointError{Name: p.Name, Err: ErrResultPointAccess, Msg: "point is read-only"}
+	}
+	table, base, count, err := argSpan(arg, true)
+	if err != nil {
+		return err
+	}
+	off, err := p.mustOffset(table, base, count)
+	if err != nil {
+		return err
+	}
+	return p.put(arg, off, value)
+}
+
+func (p *Point) get(arg *groArg, off int) (any, error) {
+	v := reflect.New(p.typ).Elem()
+	if err := p.codec.get(arg, off, v); err != nil {
+		return nil, p.wrap(err)
+	}
+	return v.Interface(), nil
+}
+
+func (p *Point) put(arg *groArg, off int, value any) error {
+	// 检查值的类型
+	v := reflect.ValueOf(value)
+	kind := v.Kind()
+	var ok bool
+	switch p.typ.Kind() {
+	case reflect.Bool, reflect.String:
+		ok = kind == p.typ.Kind()
+	default:
+		ok = kind >= reflect.Int && kind <= reflect.Float64 && kind != reflect.Uintptr ||
+			kind == reflect.Bool && p.codec.bit >= 0
+	}
+	if !ok {
+		return &PointError{Name: p.Name, Err: ErrResultRegValue, Msg: "expected " + p.typ.String() + " value, got " + fmtType(value)}
+	}
+	if p.typ.Kind() == reflect.Float64 && kind != reflect.Bool {
+		v = v.Convert(p.typ) // 整数工程值同样需要除以缩放系数
+	}
+	return p.wrap(p.codec.put(arg, off, v))
+}
+
+func fmtType(v any) string {
+	if v == nil {
+		return "nil"
+	}
+	return reflect.TypeOf(v).String()
+}
+
+func (p *Point) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	if err == ErrResultRegValue {
+		return &PointError{Name: p.Name, Err: err, Msg: "invalid " + p.Type() + " value"}
+	}
+	return &PointError{Name: p.Name, Err: err, Msg: "value out of range for " + p.Type()}
+}
+
+// 寄存器映射表
+type RegMap struct {
+	points []*Point
+	names  map[string]*Point
+}
+
+// 创建寄存器映射表
+// 返回值: 点位定义错误或名称重复时返回 *PointError (ErrResultRegMap), Line 为定义的序号 (从 1 开始)
+func NewRegMap(defs []PointDef) (*RegMap, error) {
+	m := &RegMap{names: make(map[string]*Point, len(defs))}
+	for i := range defs {
+		if err := m.add(defs[i], i+1); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *RegMap) add(def PointDef, line int) error {
+	p, err := NewPoint(def)
+	if err != nil {
+		err.(*PointError).Line = line
+		return err
+	}
+	if _, ok := m.names[p.Name]; ok {
+		return &PointError{Line: line, Name: p.Name, Err: ErrResultRegMap, Msg: "duplicate name"}
+	}
+	m.points = append(m.points, p)
+	m.names[p.Name] = p
+	return nil
+}
+
+// 从 CSV 中加载寄存器映射表
+// 第一行为列名 (不区分大小写): name/table/address/type/order/scale/unit/access/len/bit/pad, 其中 name 与 address 为必需列
+// 其他列 (如描述) 被忽略, 空值或省略的行尾列表示默认值, 以 # 开头的行为注释
+// 返回值: 定义错误时返回 *PointError (ErrResultRegMap), Line 为文件中的行号
+func LoadRegMapCSV(r io.Reader) (*RegMap, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1 // 允许省略行尾的空列
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"name", "address"} {
+		if _, ok := cols[name]; !ok {
+			return nil, &PointError{Line: 1, Err: ErrResultRegMap, Msg: "missing column " + name}
+		}
+	}
+
+	m := &RegMap{names: make(map[string]*Point)}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return m, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		get := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		def := PointDef{
+			Name:   get("name"),
+			Table:  get("table"),
+			Type:   get("type"),
+			Order:  get("order"),
+			Unit:   get("unit"),
+			Access: get("access"),
+			Pad:    get("pad"),
+		}
+		msg := ""
+		if n, ok := parseUint(get("address"), math.MaxUint16); ok {
+			def.Address = uint16(n)
+		} else {
+			msg = "invalid address " + strconv.Quote(get("address"))
+		}
+		if s := get("scale"); s != "" {
+			if def.Scale, err = strconv.ParseFloat(s, 64); err != nil {
+				msg = "invalid scale " + strconv.Quote(s)
+			}
+		}
+		if s := get("len"); s != "" {
+			n, ok := parseUint(s, math.MaxUint16)
+			if !ok {
+				msg = "invalid len " + strconv.Quote(s)
+			}
+			def.Len = uint16(n)
+		}
+		if s := get("bit"); s != "" {
+			n, ok := parseUint(s, math.MaxUint8)
+			if !ok {
+				msg = "invalid bit " + strconv.Quote(s)
+			}
+			bit := uint8(n)
+			def.Bit = &bit
+		}
+		if msg != "" {
+			return nil, &PointError{Line: line, Name: def.Name, Err: ErrResultRegMap, Msg: msg}
+		}
+		if err := m.add(def, line); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// 从 JSON 中加载寄存器映射表, 格式为点位定义 (PointDef) 的数组
+// 返回值: 定义错误时返回 *PointError (ErrResultRegMap), Line 为数组中的序号 (从 1 开始)
+func LoadRegMapJSON(r io.Reader) (*RegMap, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var defs []PointDef
+	if err := dec.Decode(&defs); err != nil {
+		return nil, err
+	}
+	return NewRegMap(defs)
+}
+
+// 全部点位 (按定义顺序)
+func (m *RegMap) Points() []*Point {
+	return m.points
+}
+
+// 依据名称查找点位, 不存在时返回 nil
+func (m *RegMap) Point(name string) *Point {
+	return m.names[name]
+}
+
+// 从寄存器值 (如解析的响应报文) 中解码全部可读点位的工程值
+// 仅解码与 Arg 功能码的数据表相同且完整位于 [GetRegAddr, GetRegAddr+GetRegLen) 范围内的点位, 以名称为键
+// 返回值: 点位跨越范围边界或寄存器值无效时返回错误
+func (m *RegMap) Decode(arg *groArg) (map[string]any, error) {
+	table, base, count, err := argSpan(arg, false)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]any)
+	for _, p := range m.points {
+		if p.Access&AccessRead == 0 {
+			continue
+		}
+		off, in, err := p.offset(table, base, count)
+		if err != nil {
+			return nil, err
+		}
+		if !in {
+			continue
+		}
+		if values[p.Name], err = p.get(arg, off); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// 将以名称为键的工程值编码为寄存器值 (如写多个保持寄存器的请求)
+// 调用前需通过 Arg.Init 设置功能码、起始地址与数量, 每个点位需完整位于该范围内
+// 返回值: 点位不存在、不可写、不在范围内或值超出原始类型范围时返回错误
+func (m *RegMap) Encode(arg *groArg, values map[string]any) error {
+	for name := range values {
+		if p := m.names[name]; p == nil {
+			return &PointError{Name: name, Err: ErrResultRegMap, Msg: "unknown point"}
+		} else if p.Access&AccessWrite == 0 {
+			return &PointError{Name: name, Err: ErrResultPointAccess, Msg: "point is read-only"}
+		}
+	}
+	table, base, count, err := argSpan(arg, true)
+	if err != nil {
+		return err
+	}
+	for _, p := range m.points {
+		v, ok := values[p.Name]
+		if !ok {
+			continue
+		}
+		off, err := p.mustOffset(table, base, count)
+		if err != nil {
+			return err
+		}
+		if err := p.put(arg, off, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
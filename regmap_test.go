@@ -0,0 +1,223 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testRegMapCSV = `Name,Table,Address,Type,Order,Scale,Unit,Access,Len,Bit,Description
+# 运行数据
+voltage,input,0x0000,uint16,,0.1,V,,,,Phase A voltage
+power,input,1,float32,CDAB,,kW,,,,
+energy,input,3,uint32,,0.01,kWh,,,,
+temp,input,5,int16,,,,,,,
+alarm,input,6,bool,,,,,,0,
+mode,input,6,uint16,,,,,3,4,
+serial,input,7,string,,,,,2,,
+
+setpoint,hold,100,int32,DCBA,,,rw,,,
+command,hold,102,,,,,w,,,
+enable,coil,0,,,,,,,,
+door,discrete,0x10,,,,,,,,
+`
+
+func TestRegMap(t *testing.T) {
+	m, err := LoadRegMapCSV(strings.NewReader(testRegMapCSV))
+	if err != nil {
+		t.Fatalf("Failed to load CSV: %v.\n", err)
+	}
+	if len(m.Points()) != 11 {
+		t.Fatalf("Expected 11 points, got %d.\n", len(m.Points()))
+	}
+	p := m.Point("power")
+	if p == nil || p.Table() != TableInput || p.Addr() != 1 || p.Size() != 2 || p.Type() != "float32" ||
+		p.Order() != OrderCDAB || p.Unit != "kW" || p.Access != AccessRead {
+		t.Fatalf("Unexpected point power: %+v.\n", p)
+	}
+	if p := m.Point("alarm"); p.Type() != "bool" {
+		t.Fatalf("Expected alarm type bool, got %s.\n", p.Type())
+	}
+
+	// 解码读输入寄存器的响应
+	var arg groArg
+	arg.Init(FuncCodeReadInput, 0x0000, 9)
+	arg.SetU8s(strToHex("0901" + "0000 3F80" + "0001 E240" + "FFD8" + "0051" + "4142 4300"))
+	values, err := m.Decode(&arg)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v.\n", err)
+	}
+	expect := map[string]any{
+		"voltage": 230.5,
+		"power":   float64(1),
+		"energy":  1234.56,
+		"temp":    int64(-40),
+		"alarm":   true,
+		"mode":    uint64(5),
+		"serial":  "ABC",
+	}
+	if !reflect.DeepEqual(values, expect) {
+		t.Fatalf("Decode mismatch:\nExpected = %v\nActual   = %v.\n", expect, values)
+	}
+
+	// 编码写多个保持寄存器的请求
+	arg.Init(FuncCodeWriteHolds, 100, 3)
+	if err := m.Encode(&arg, map[string]any{"setpoint": -2, "command": uint16(0xA5)}); err != nil {
+		t.Fatalf("Failed to encode: %v.\n", err)
+	}
+	if got := arg.GetU8s(); !bytes.Equal(got, strToHex("FEFF FFFF 00A5")) {
+		t.Fatalf("Encode mismatch: %x.\n", got)
+	}
+
+	// 单个点位
+	arg.Init(FuncCodeWriteCoil, 0, 1)
+	if err := m.Point("enable").Encode(&arg, true); err != nil {
+		t.Fatalf("Failed to encode coil: %v.\n", err)
+	}
+	arg.Init(FuncCodeReadDiscrete, 0x10, 1)
+	arg.SetU8s([]uint8{0x01})
+	if v, err := m.Point("door").Decode(&arg); err != nil || v != true {
+		t.Fatalf("Expected door true, got %v, %v.\n", v, err)
+	}
+
+	// 整数工程值同样依据缩放系数编码
+	p, err = NewPoint(PointDef{Name: "limit", Address: 0, Scale: 0.1})
+	if err != nil {
+		t.Fatalf("Failed to create point: %v.\n", err)
+	}
+	arg.Init(FuncCodeWriteHold, 0, 1)
+	if err := p.Encode(&arg, 25); err != nil || !bytes.Equal(arg.GetU8s(), strToHex("00FA")) {
+		t.Fatalf("Expected 00FA, got %x, %v.\n", arg.GetU8s(), err)
+	}
+}
+
+func TestRegMapJSON(t *testing.T) {
+	m, err := LoadRegMapJSON(strings.NewReader(`[
+		{"name": "freq", "table": "hold", "address": 10, "type": "bcd16", "scale": 0.01, "unit": "Hz"},
+		{"name": "model", "address": 11, "type": "string", "len": 2, "pad": "space", "order": "BADC"}
+	]`))
+	if err != nil {
+		t.Fatalf("Failed to load JSON: %v.\n", err)
+	}
+
+	var arg groArg
+	arg.Init(FuncCodeWriteHolds, 10, 3)
+	if err := m.Encode(&arg, map[string]any{"freq": 50.0, "model": "X1"}); err != nil {
+		t.Fatalf("Failed to encode: %v.\n", err)
+	}
+	if got := arg.GetU8s(); !bytes.Equal(got, strToHex("5000 3158 2020")) {
+		t.Fatalf("Encode mismatch: %x.\n", got)
+	}
+	data := bytes.Clone(arg.GetU8s())
+	arg.Init(FuncCodeReadHold, 10, 3)
+	arg.SetU8s(data)
+	values, err := m.Decode(&arg)
+	if err != nil || values["freq"] != 50.0 || values["model"] != "X1" {
+		t.Fatalf("Decode mismatch: %v, %v.\n", values, err)
+	}
+
+	if _, err := LoadRegMapJSON(strings.NewReader(`[{"name": "a", "address": 1, "scal": 1}]`)); err == nil {
+		t.Fatalf("Expected error for unknown key.\n")
+	}
+}
+
+func TestRegMapErrors(t *testing.T) {
+	tests := []struct {
+		csv  string
+		line int
+	}{
+		{"name,address\nv,0x10000\n", 2},                                                    // 地址超出范围
+		{"name,address,type\na,1,uint16\nb,0xFFFF,uint32\n", 3},                             // 结束地址超出 0xFFFF
+		{"name,address,type,len\ns,0,string,126\n", 2},                                      // 超出单个读请求的数量
+		{"name,address,type,len,access\ns,0,string,124,rw\n", 2},                            // 超出单个写请求的数量
+		{"name,table,address,access\nv,input,0,rw\n", 2},                                    // 只读数据表
+		{"name,table,address,type\nc,coil,0,uint16\n", 2},                                   // 线圈类型错误
+		{"name,address,type\nv,0,int24\n", 2},                                               // 未知类型
+		{"name,address,bit,len\nb,0,14,3\n", 2},                                             // 位字段超出 16 位
+		{"name,address\n\n\n# comment\nv,1\nv,2\n", 6},                                      // 名称重复
+		{"name,address,scale\nv,1,x\n", 2},                                                  // 缩放系数错误
+		{"name,table\nv,hold\n", 1},                                                         // 缺少列
+		{"name,address,order,type\nv,0,XYZW,uint32\n", 2},                                   // 字顺序错误
+		{"name,table,address,type,access\nb,hold,0,bool,rw\n", 2},                           // 寄存器中的 bool 需要 bit
+		{"name,address,type,pad\nv,0,uint16,space\n", 2},                                    // 填充字符仅用于字符串
+		{"name,table,address,type,scale\nb,coil,0,bool,0.5\n", 2},                           // bool 不能缩放
+		{"name,table,address,type,access\nv,hold,0,uint16,x\n", 2},                          // 访问权限错误
+		{"name,table,address\nv,coils,0\n", 2},                                              // 数据表错误
+		{"name,address,type,len\nv,0,string,0\n", 2},                                        // 字符串需要长度
+		{"name,address,bit\nv,0,16\n", 2},                                                   // 起始位错误
+		{"name,address\n,1\n", 2},                                                           // 缺少名称
+		{"name,table,address,len\nv,coil,0,2\n", 2},                                         // 线圈不支持长度
+		{"name,address,type,bit\nv,0,float32,0\n", 2},                                       // 位字段需要 uint16
+		{"name,address,type,len\nv,0,uint32,2\n", 2},                                        // 长度仅用于字符串与位字段
+		{"name,address,type,scale\nv,0,string,0.1\n", 2},                                    // 字符串不能缩放
+		{"name,table,address,len\nv,discrete,0,1\n", 2},                                     // 离散量输入不支持长度
+		{"name,table,address,type\nv,input,0xFFFE,float64\n", 2},                            // 结束地址超出 0xFFFF
+		{"name,table,address,type,len,access\ns,hold,0,string,123,w\ns,hold,0,uint16\n", 3}, // 名称重复
+	}
+	for _, tt := range tests {
+		_, err := LoadRegMapCSV(strings.NewReader(tt.csv))
+		var perr *PointError
+		if !errors.Is(err, ErrResultRegMap) || !errors.As(err, &perr) || perr.Line != tt.line {
+			t.Errorf("%q: expected ErrResultRegMap at line %d, got %v.\n", tt.csv, tt.line, err)
+		}
+	}
+
+	m, err := LoadRegMapCSV(strings.NewReader(testRegMapCSV))
+	if err != nil {
+		t.Fatalf("Failed to load CSV: %v.\n", err)
+	}
+	var arg groArg
+
+	// 点位访问权限
+	arg.Init(FuncCodeWriteHolds, 0, 1)
+	if err := m.Encode(&arg, map[string]any{"voltage": 1.0}); !errors.Is(err, ErrResultPointAccess) {
+		t.Fatalf("Expected ErrResultPointAccess for read-only point, got %v.\n", err)
+	}
+	arg.Init(FuncCodeReadHold, 102, 1)
+	arg.SetU8s(strToHex("0000"))
+	if _, err := m.Point("command").Decode(&arg); !errors.Is(err, ErrResultPointAccess) {
+		t.Fatalf("Expected ErrResultPointAccess for write-only point, got %v.\n", err)
+	}
+	if values, err := m.Decode(&arg); err != nil || len(values) != 0 {
+		t.Fatalf("Expected write-only point to be skipped, got %v, %v.\n", values, err)
+	}
+
+	// 点位不在范围内或跨越范围边界
+	arg.Init(FuncCodeWriteHolds, 101, 2)
+	if err := m.Encode(&arg, map[string]any{"setpoint": 1}); !errors.Is(err, ErrResultFieldRange) {
+		t.Fatalf("Expected ErrResultFieldRange, got %v.\n", err)
+	}
+	arg.Init(FuncCodeReadInput, 2, 4)
+	arg.SetU8s(make([]uint8, 8))
+	if _, err := m.Decode(&arg); !errors.Is(err, ErrResultFieldRange) {
+		t.Fatalf("Expected ErrResultFieldRange for power, got %v.\n", err)
+	}
+	if _, err := m.Point("serial").Decode(&arg); !errors.Is(err, ErrResultFieldRange) {
+		t.Fatalf("Expected ErrResultFieldRange for serial, got %v.\n", err)
+	}
+
+	// 值的类型与范围
+	arg.Init(FuncCodeWriteHolds, 100, 3)
+	for _, values := range []map[string]any{
+		{"setpoint": "1"},
+		{"command": true},
+		{"command": nil},
+	} {
+		if err := m.Encode(&arg, values); !errors.Is(err, ErrResultRegValue) {
+			t.Fatalf("%v: expected ErrResultRegValue, got %v.\n", values, err)
+		}
+	}
+	if err := m.Encode(&arg, map[string]any{"command": 0x10000}); !errors.Is(err, ErrResultFieldRange) {
+		t.Fatalf("Expected ErrResultFieldRange for command, got %v.\n", err)
+	}
+	if err := m.Encode(&arg, map[string]any{"unknown": 1}); !errors.Is(err, ErrResultRegMap) {
+		t.Fatalf("Expected ErrResultRegMap for unknown point, got %v.\n", err)
+	}
+}
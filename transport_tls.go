@@ -0,0 +1,233 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"net"
+	"time"
+)
+
+const (
+	TLSPort             = 802              // Modbus/TCP Security 默认端口
+	tlsDefaultTimeout   = 3 * time.Second  // 默认响应超时时间
+	tlsHandshakeTimeout = 10 * time.Second // 从站默认握手超时时间
+	tlsIdleTimeout      = 60 * time.Second // 从站默认空闲超时时间
+)
+
+// 角色扩展的 OID (Modbus/TCP Security), 值为 ASN.1 UTF8String
+var OidModbusRole = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 50316, 802, 1}
+
+// 获取证书中的角色
+// 返回值: 证书不含角色扩展时返回空字符串, 扩展格式错误或重复时返回 ErrResultTlsRole
+func RoleFromCertificate(cert *x509.Certificate) (string, error) {
+	role, found := "", false
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(OidModbusRole) {
+			continue
+		}
+		if found {
+			return "", ErrResultTlsRole
+		}
+		if rest, err := asn1.Unmarshal(ext.Value, &role); err != nil || len(rest) != 0 {
+			return "", ErrResultTlsRole
+		}
+		found = true
+	}
+	return role, nil
+}
+
+// 创建从站 TLS 配置: 双向认证, 仅接受 clientCAs 签发的客户端证书, 最低 TLS 1.2
+func NewTLSServerConfig(cert tls.Certificate, clientCAs *x509.CertPool) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// 创建主站 TLS 配置: 提供客户端证书, 使用 rootCAs 校验从站证书, 最低 TLS 1.2
+func NewTLSClientConfig(cert tls.Certificate, rootCAs *x509.CertPool, serverName string) *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      rootCAs,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// Modbus/TCP Security 主站
+// 每次请求前应调用 Head.IncSerNum 更新流水号, 以便丢弃之前超时的请求的响应
+type TLSClient struct {
+	conn    *tls.Conn
+	reader  *TCPFrameReader
+	timeout time.Duration // 响应超时时间
+}
+
+// 连接从站并完成 TLS 握手 (address 通常为 "host:802")
+func DialTLS(address string, config *tls.Config) (*TLSClient, error) {
+	dialer := &net.Dialer{Timeout: tlsDefaultTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, config)
+	if err != nil {
+		return nil, err
+	}
+	return NewTLSClient(conn), nil
+}
+
+func NewTLSClient(conn *tls.Conn) *TLSClient {
+	return &TLSClient{conn: conn, reader: NewTCPFrameReader(conn), timeout: tlsDefaultTimeout}
+}
+
+// 设置 响应超时时间
+func (c *TLSClient) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+func (c *TLSClient) Close() error {
+	return c.conn.Close()
+}
+
+// 发送请求报文并接收响应报文 (可用作 Transact)
+// 丢弃流水号不一致的响应
+// 返回值: 响应报文 (复制后的数据)
+func (c *TLSClient) Transact(req []uint8) ([]uint8, error) {
+	if len(req) < mbapHeadLen+2 || len(req) > MaxTCPFrameLen {
+		return nil, ErrResultLength
+	}
+	sernum := binary.BigEndian.Uint16(req[0:2])
+
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := c.conn.Write(req); err != nil {
+		return nil, err
+	}
+	for {
+		b, err := c.reader.ReadFrame()
+		if err != nil {
+			return nil, err
+		}
+		if binary.BigEndian.Uint16(b[0:2]) == sernum {
+			return append([]uint8(nil), b...), nil
+		}
+	}
+}
+
+// Modbus/TCP Security 从站
+// 每个连接使用独立的 Modbus 实例, 客户端证书中的角色保存在 Access.Role 中
+type TLSServer struct {
+	ln        net.Listener
+	setup     func(m *Modbus) // 初始化连接的 Modbus 实例 (如访问控制器)
+	serve     func(m *Modbus) // 处理请求 (解析成功且无异常时调用)
+	handshake time.Duration   // 握手超时时间
+	idle      time.Duration   // 空闲超时时间 (等待请求帧)
+}
+
+// 创建 Modbus/TCP Security 从站
+// ln 应由 tls.Listen/tls.NewListener 创建, 并使用 NewTLSServerConfig 的配置
+// serve 可能被多个连接并发调用
+// setup 可通过 m.Access.SetCheckRole/SetCheckRoleAddr 依据角色限制可使用的功能码与地址
+func NewTLSServer(ln net.Listener, setup, serve func(m *Modbus)) *TLSServer {
+	return &TLSServer{ln: ln, setup: setup, serve: serve, handshake: tlsHandshakeTimeout, idle: tlsIdleTimeout}
+}
+
+// 设置 握手超时时间 (0 表示不限制), 客户端未在时限内完成握手时关闭连接
+// 需在 Serve 之前设置
+func (s *TLSServer) SetHandshakeTimeout(timeout time.Duration) {
+	s.handshake = timeout
+}
+
+// 设置 空闲超时时间 (0 表示不限制), 时限内未收到完整的请求帧时关闭连接
+// 需在 Serve 之前设置
+func (s *TLSServer) SetIdleTimeout(timeout time.Duration) {
+	s.idle = timeout
+}
+
+// 依据超时时间计算截止时间 (0 表示不限制)
+func tlsDeadline(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
+
+// 接受并处理连接, 直至监听失败 (如监听关闭)
+func (s *TLSServer) Serve() error {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			_ = s.ServeConn(conn)
+		}()
+	}
+}
+
+// 处理单个连接, 直至连接关闭或出错
+// 握手失败或超时、空闲超时、证书的角色扩展无效或 MBAP 帧错误时关闭连接
+func (s *TLSServer) ServeConn(conn net.Conn) error {
+	defer conn.Close()
+
+	tconn, ok := conn.(*tls.Conn)
+	if !ok {
+		return ErrResultTlsCert
+	}
+	if err := tconn.SetDeadline(tlsDeadline(s.handshake)); err != nil {
+		return err
+	}
+	if err := tconn.Handshake(); err != nil {
+		return err
+	}
+	if err := tconn.SetDeadline(time.Time{}); err != nil {
+		return err
+	}
+	certs := tconn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return ErrResultTlsCert
+	}
+	role, err := RoleFromCertificate(certs[0])
+	if err != nil {
+		return err
+	}
+
+	m := New()
+	if s.setup != nil {
+		s.setup(m)
+	}
+	m.Head.SetProtocol(ProtocolTCP)
+	m.Access.SetRole(role)
+
+	reader := NewTCPFrameReader(tconn)
+	var rsp []uint8
+	for {
+		// 截止时间同时限制响应的发送 (客户端不再读取时)
+		if err := tconn.SetDeadline(tlsDeadline(s.idle)); err != nil {
+			return err
+		}
+		b, err := reader.ReadFrame()
+		if err != nil {
+			return err
+		}
+		// 格式错误或无需响应的请求直接丢弃
+		if err := m.ParseRequest(b); err != nil {
+			continue
+		}
+		if s.serve != nil && m.Result.GetExcepCode() == ExcepNormal {
+			s.serve(m)
+		}
+		if rsp, err = m.AppendResponse(rsp[:0]); err != nil {
+			return err
+		}
+		if _, err := tconn.Write(rsp); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// 生成证书, parent 为空时生成自签名的 CA 证书
+func testCert(t *testing.T, name string, parent *tls.Certificate, exts ...pkix.Extension) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v.\n", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:    big.NewInt(time.Now().UnixNano()),
+		Subject:         pkix.Name{CommonName: name},
+		DNSNames:        []string{name},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		ExtraExtensions: exts,
+	}
+
+	signer, signKey := tmpl, any(key)
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v.\n", err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]uint8{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func testRoleExt(t *testing.T, role string) pkix.Extension {
+	t.Helper()
+	value, err := asn1.MarshalWithParams(role, "utf8")
+	if err != nil {
+		t.Fatalf("Failed to marshal role: %v.\n", err)
+	}
+	return pkix.Extension{Id: OidModbusRole, Value: value}
+}
+
+func TestRoleFromCertificate(t *testing.T) {
+	ca := testCert(t, "ca", nil)
+	if role, err := RoleFromCertificate(testCert(t, "a", &ca, testRoleExt(t, "Operator")).Leaf); err != nil || role != "Operator" {
+		t.Fatalf("Expected role Operator, got %q, %v.\n", role, err)
+	}
+	if role, err := RoleFromCertificate(ca.Leaf); err != nil || role != "" {
+		t.Fatalf("Expected empty role, got %q, %v.\n", role, err)
+	}
+	bad := pkix.Extension{Id: OidModbusRole, Value: []uint8{0x0C, 0x05, 'a'}}
+	if _, err := RoleFromCertificate(testCert(t, "b", &ca, bad).Leaf); !errors.Is(err, ErrResultTlsRole) {
+		t.Fatalf("Expected ErrResultTlsRole, got %v.\n", err)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	ca := testCert(t, "ca", nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", NewTLSServerConfig(testCert(t, "localhost", &ca), pool))
+	if err != nil {
+		t.Skipf("TCP unavailable: %v.\n", err)
+	}
+	defer ln.Close()
+
+	// Viewer 仅允许读取, 且只能访问地址 0x0000~0x0009; Operator 可读写全部地址
+	server := NewTLSServer(ln, func(m *Modbus) {
+		m.Access.SetCheckRole(func(role string, funccode uint8, userdata any) bool {
+			switch role {
+			case "Operator":
+				return true
+			case "Viewer":
+				return funccode == FuncCodeReadHold
+			}
+			return false
+		})
+		m.Access.SetCheckHold(checkDefault)
+		m.Access.SetCheckRoleAddr(func(role string, table Table, regaddr, reglen uint16, isRead bool, userdata any) bool {
+			return role == "Operator" || uint32(regaddr)+uint32(reglen) <= 0x000A
+		})
+	}, func(m *Modbus) {
+		if m.Arg.GetFuncCode() == FuncCodeReadHold {
+			m.Arg.SetU16s(make([]uint16, m.Arg.GetRegLen()), binary.BigEndian)
+		}
+	})
+	go server.Serve()
+
+	dial := func(cert tls.Certificate) *TLSClient {
+		client, err := DialTLS(ln.Addr().String(), NewTLSClientConfig(cert, pool, "localhost"))
+		if err != nil {
+			t.Fatalf("Failed to dial: %v.\n", err)
+		}
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+	request := func(client *TLSClient, funccode uint8, regaddr uint16) error {
+		m := New()
+		m.Head.InitTcp(0x01, 0x0001)
+		m.Arg.Init(funccode, regaddr, 0x0001)
+		m.Arg.SetU16s([]uint16{0x0001}, binary.BigEndian)
+		req, _ := m.AppendRequest(nil)
+		rsp, err := client.Transact(req)
+		if err != nil {
+			return err
+		}
+		return m.ParseResponse(rsp)
+	}
+
+	tests := []struct {
+		role     string
+		funccode uint8
+		regaddr  uint16
+		expected error
+	}{
+		{"Viewer", FuncCodeReadHold, 0x0000, nil},
+		{"Viewer", FuncCodeReadHold, 0x0064, ErrIllDataAddr},
+		{"Viewer", FuncCodeWriteHold, 0x0000, ErrIllFuncCode},
+		{"Operator", FuncCodeWriteHold, 0x0064, nil},
+		{"Guest", FuncCodeReadHold, 0x0000, ErrIllFuncCode},
+	}
+	for _, tt := range tests {
+		client := dial(testCert(t, "client", &ca, testRoleExt(t, tt.role)))
+		if err := request(client, tt.funccode, tt.regaddr); !errors.Is(err, tt.expected) {
+			t.Fatalf("%s %s: expected %v, got %v.\n", tt.role, FuncCodeToString(tt.funccode), tt.expected, err)
+		}
+	}
+
+	// 证书不是由受信任的 CA 签发
+	other := testCert(t, "other", nil)
+	client := dial(testCert(t, "client", &other, testRoleExt(t, "Operator")))
+	if err := request(client, FuncCodeReadHold, 0x0000); err == nil {
+		t.Fatalf("Expected untrusted client certificate to be rejected.\n")
+	}
+
+	// 非 TLS 连接
+	conn, _ := net.Pipe()
+	if err := server.ServeConn(conn); !errors.Is(err, ErrResultTlsCert) {
+		t.Fatalf("Expected ErrResultTlsCert, got %v.\n", err)
+	}
+}
+
+func TestTLSTimeout(t *testing.T) {
+	ca := testCert(t, "ca", nil)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", NewTLSServerConfig(testCert(t, "localhost", &ca), pool))
+	if err != nil {
+		t.Skipf("TCP unavailable: %v.\n", err)
+	}
+	defer ln.Close()
+	server := NewTLSServer(ln, nil, nil)
+	server.SetHandshakeTimeout(100 * time.Millisecond)
+	server.SetIdleTimeout(100 * time.Millisecond)
+	go server.Serve()
+
+	// 等待从站关闭连接 (读取返回非超时错误)
+	closed := func(conn net.Conn) bool {
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, err := conn.Read(make([]uint8, 1))
+		return err != nil && !isTimeout(err)
+	}
+
+	// 未开始握手
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Failed to dial: %v.\n", err)
+	}
+	defer conn.Close()
+	if !closed(conn) {
+		t.Fatalf("Expected stalled handshake to be closed.\n")
+	}
+
+	// 握手后不发送请求
+	cert := testCert(t, "client", &ca, testRoleExt(t, "Operator"))
+	tconn, err := tls.Dial("tcp", ln.Addr().String(), NewTLSClientConfig(cert, pool, "localhost"))
+	if err != nil {
+		t.Fatalf("Failed to dial: %v.\n", err)
+	}
+	defer tconn.Close()
+	if !closed(tconn) {
+		t.Fatalf("Expected idle connection to be closed.\n")
+	}
+}
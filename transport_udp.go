@@ -0,0 +1,179 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"time"
+)
+
+const (
+	udpDefaultTimeout = time.Second     // 默认响应超时时间
+	udpDefaultRetries = 2               // 默认重发次数
+	udpDupWindow      = 5 * time.Second // 重复请求的判断时长
+	udpMaxCache       = 1024            // 响应缓存的最大条目数
+)
+
+// 检查数据报是否为单个完整的 MBAP 帧
+func udpCheckFrame(b []uint8) bool {
+	return len(b) >= mbapHeadLen+2 && len(b) <= MaxTCPFrameLen &&
+		binary.BigEndian.Uint16(b[2:4]) == 0x0000 &&
+		int(binary.BigEndian.Uint16(b[4:6])) == len(b)-mbapHeadLen
+}
+
+// Modbus UDP 主站
+// 每个数据报承载一个 MBAP 帧, 依据流水号匹配响应, 超时后重发请求
+// 每次请求前应调用 Head.IncSerNum 更新流水号, 以便丢弃之前请求的重复/延迟响应
+type UDPClient struct {
+	conn    net.PacketConn
+	addr    net.Addr
+	timeout time.Duration         // 响应超时时间
+	retries int                   // 重发次数
+	buf     [MaxTCPFrameLen]uint8 // 接收缓冲区
+}
+
+func NewUDPClient(conn net.PacketConn, addr net.Addr) *UDPClient {
+	return &UDPClient{conn: conn, addr: addr, timeout: udpDefaultTimeout, retries: udpDefaultRetries}
+}
+
+// 设置 响应超时时间
+func (c *UDPClient) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// 设置 超时后的重发次数
+func (c *UDPClient) SetRetries(retries int) {
+	c.retries = retries
+}
+
+// 发送请求报文并接收响应报文 (可用作 Transact)
+// 丢弃来自其他地址、流水号不一致或格式错误的数据报
+// 返回值: 响应报文 (复制后的数据), 全部重发均超时时返回超时错误
+func (c *UDPClient) Transact(req []uint8) ([]uint8, error) {
+	if !udpCheckFrame(req) {
+		return nil, ErrResultLength
+	}
+	sernum := binary.BigEndian.Uint16(req[0:2])
+
+	var err error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if _, err = c.conn.WriteTo(req, c.addr); err != nil {
+			return nil, err
+		}
+
+		var rsp []uint8
+		rsp, err = c.receive(sernum, time.Now().Add(c.timeout))
+		if err == nil {
+			return rsp, nil
+		}
+		if !isTimeout(err) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// 接收流水号一致的响应, 直至超时
+func (c *UDPClient) receive(sernum uint16, deadline time.Time) ([]uint8, error) {
+	if err := c.conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	for {
+		n, addr, err := c.conn.ReadFrom(c.buf[:])
+		if err != nil {
+			return nil, err
+		}
+		b := c.buf[:n]
+		if addr.String() != c.addr.String() || !udpCheckFrame(b) || binary.BigEndian.Uint16(b[0:2]) != sernum {
+			continue
+		}
+		return append([]uint8(nil), b...), nil
+	}
+}
+
+// 已发送的响应 (用于重复请求)
+type udpCacheEntry struct {
+	sernum uint16    // 流水号
+	req    []uint8   // 请求报文
+	rsp    []uint8   // 响应报文
+	at     time.Time // 发送时间
+}
+
+// Modbus UDP 从站
+// 每个数据报承载一个 MBAP 帧; 同一地址重发的请求 (流水号与报文均相同) 直接回复之前的响应, 不重复处理
+type UDPServer struct {
+	conn  net.PacketConn
+	m     *Modbus                  // 解析请求/封装响应
+	serve func(m *Modbus)          // 处理请求 (解析成功且无异常时调用)
+	cache map[string]udpCacheEntry // 各地址最近一次的响应
+	buf   [MaxTCPFrameLen]uint8    // 接收缓冲区
+}
+
+// 创建 Modbus UDP 从站
+// m 的访问控制器需由调用方设置, serve 可设置 m.Arg 作为响应 (如读保持寄存器的值)
+func NewUDPServer(conn net.PacketConn, m *Modbus, serve func(m *Modbus)) *UDPServer {
+	m.Head.SetProtocol(ProtocolTCP)
+	return &UDPServer{conn: conn, m: m, serve: serve, cache: make(map[string]udpCacheEntry)}
+}
+
+// 接收并处理请求, 直至读取数据报失败 (如连接关闭)
+func (s *UDPServer) Serve() error {
+	for {
+		n, addr, err := s.conn.ReadFrom(s.buf[:])
+		if err != nil {
+			return err
+		}
+		s.handle(s.buf[:n], addr)
+	}
+}
+
+// 处理单个数据报, 格式错误或无需响应的请求直接丢弃
+// 发送失败时由主站超时重发, 不中断服务
+func (s *UDPServer) handle(b []uint8, addr net.Addr) {
+	if !udpCheckFrame(b) {
+		return
+	}
+	key := addr.String()
+	sernum := binary.BigEndian.Uint16(b[0:2])
+	now := time.Now()
+
+	// 重复请求: 回复之前的响应
+	if e, ok := s.cache[key]; ok && e.sernum == sernum && bytes.Equal(e.req, b) && now.Sub(e.at) < udpDupWindow {
+		_, _ = s.conn.WriteTo(e.rsp, addr)
+		return
+	}
+
+	m := s.m
+	if err := m.ParseRequest(b); err != nil {
+		return
+	}
+	if s.serve != nil && m.Result.GetExcepCode() == ExcepNormal {
+		s.serve(m)
+	}
+	rsp, err := m.AppendResponse(nil)
+	if err != nil {
+		return
+	}
+
+	s.store(key, udpCacheEntry{sernum: sernum, req: bytes.Clone(b), rsp: rsp, at: now})
+	_, _ = s.conn.WriteTo(rsp, addr)
+}
+
+// 保存响应, 缓存过多时清除过期的条目
+func (s *UDPServer) store(key string, e udpCacheEntry) {
+	if len(s.cache) >= udpMaxCache {
+		for k, v := range s.cache {
+			if e.at.Sub(v.at) >= udpDupWindow {
+				delete(s.cache, k)
+			}
+		}
+	}
+	if _, ok := s.cache[key]; ok || len(s.cache) < udpMaxCache {
+		s.cache[key] = e
+	}
+}
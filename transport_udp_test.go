@@ -0,0 +1,179 @@
+// Copyright 2025 The Gromb Authors. All rights reserved.
+//
+// Use of this source code is governed by an MIT-style
+// license that can be found in the LICENSE file.
+
+package gromb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 丢弃前 drop 个数据报的连接
+type lossyConn struct {
+	net.PacketConn
+	drop int
+}
+
+func (c *lossyConn) ReadFrom(p []uint8) (int, net.Addr, error) {
+	for {
+		n, addr, err := c.PacketConn.ReadFrom(p)
+		if err != nil || c.drop == 0 {
+			return n, addr, err
+		}
+		c.drop--
+	}
+}
+
+// 启动 UDP 从站, 返回从站地址与写保持寄存器的处理次数
+func testUDPServer(t *testing.T, drop int) (net.Addr, *atomic.Int32) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP unavailable: %v.\n", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var writes atomic.Int32
+	slave := New()
+	slave.Access.SetCheckHold(checkDefault)
+	server := NewUDPServer(&lossyConn{PacketConn: conn, drop: drop}, slave, func(m *Modbus) {
+		switch m.Arg.GetFuncCode() {
+		case FuncCodeReadHold:
+			m.Arg.SetU16s([]uint16{0x1234, 0x5678}, binary.BigEndian)
+		case FuncCodeWriteHold:
+			writes.Add(1)
+		}
+	})
+	go server.Serve()
+	return conn.LocalAddr(), &writes
+}
+
+func testUDPClient(t *testing.T, addr net.Addr) (*UDPClient, net.PacketConn) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP unavailable: %v.\n", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	client := NewUDPClient(conn, addr)
+	client.SetTimeout(100 * time.Millisecond)
+	return client, conn
+}
+
+func TestUDP(t *testing.T) {
+	// 第一个请求丢失, 超时后重发
+	addr, _ := testUDPServer(t, 1)
+	client, _ := testUDPClient(t, addr)
+
+	master := New()
+	master.Head.InitTcp(0x01, 0x0001)
+	master.Arg.Init(FuncCodeReadHold, 0x0000, 0x0002)
+	req, _ := master.AppendRequest(nil)
+	rsp, err := client.Transact(req)
+	if err != nil {
+		t.Fatalf("Failed to transact: %v.\n", err)
+	}
+	if err := master.ParseResponse(rsp); err != nil {
+		t.Fatalf("Failed to parse response: %v.\n", err)
+	}
+	if got := master.Arg.GetU16s(binary.BigEndian); len(got) != 2 || got[0] != 0x1234 || got[1] != 0x5678 {
+		t.Fatalf("Values mismatch: %04X.\n", got)
+	}
+
+	// 重发次数用尽
+	lost, _ := testUDPServer(t, 1)
+	client2, _ := testUDPClient(t, lost)
+	client2.SetRetries(0)
+	if _, err := client2.Transact(req); !isTimeout(err) {
+		t.Fatalf("Expected timeout, got %v.\n", err)
+	}
+}
+
+func TestUDPDuplicate(t *testing.T) {
+	addr, writes := testUDPServer(t, 0)
+	client, conn := testUDPClient(t, addr)
+
+	master := New()
+	master.Head.InitTcp(0x01, 0x0010)
+	master.Arg.Init(FuncCodeWriteHold, 0x0001, 0x0001)
+	master.Arg.SetU16s([]uint16{0x00FF}, binary.BigEndian)
+	req, _ := master.AppendRequest(nil)
+
+	// 重复的请求只处理一次, 多余的响应在下一次请求时被丢弃
+	if _, err := conn.WriteTo(req, addr); err != nil {
+		t.Fatalf("Failed to write: %v.\n", err)
+	}
+	rsp, err := client.Transact(req)
+	if err != nil {
+		t.Fatalf("Failed to transact: %v.\n", err)
+	}
+	if err := master.ParseResponse(rsp); err != nil {
+		t.Fatalf("Failed to parse response: %v.\n", err)
+	}
+	if n := writes.Load(); n != 1 {
+		t.Fatalf("Expected 1 write, got %d.\n", n)
+	}
+
+	master.Head.IncSerNum()
+	master.Arg.Init(FuncCodeReadHold, 0x0000, 0x0002)
+	req, _ = master.AppendRequest(nil)
+	rsp, err = client.Transact(req)
+	if err != nil {
+		t.Fatalf("Failed to transact: %v.\n", err)
+	}
+	if err := master.ParseResponse(rsp); err != nil {
+		t.Fatalf("Failed to parse response after duplicate: %v.\n", err)
+	}
+
+	// 流水号相同但报文不同 (如主站重启后流水号重复) 的请求需要处理
+	master.Arg.Init(FuncCodeWriteHold, 0x0001, 0x0001)
+	master.Arg.SetU16s([]uint16{0x00FF}, binary.BigEndian)
+	req, _ = master.AppendRequest(nil)
+	rsp, err = client.Transact(req)
+	if err != nil {
+		t.Fatalf("Failed to transact: %v.\n", err)
+	}
+	if err := master.ParseResponse(rsp); err != nil {
+		t.Fatalf("Failed to parse response with reused serial number: %v.\n", err)
+	}
+	if n := writes.Load(); n != 2 {
+		t.Fatalf("Expected 2 writes, got %d.\n", n)
+	}
+}
+
+func TestUDPLate(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP unavailable: %v.\n", err)
+	}
+	defer server.Close()
+	client, _ := testUDPClient(t, server.LocalAddr())
+
+	// 先回复流水号不一致的延迟响应与格式错误的数据报, 再回复正确的响应
+	late := strToHex("0001 0000 0006 01 06 0001 00FF")
+	expect := strToHex("0002 0000 0006 01 06 0001 00FF")
+	go func() {
+		var buf [MaxTCPFrameLen]uint8
+		_, addr, err := server.ReadFrom(buf[:])
+		if err != nil {
+			return
+		}
+		server.WriteTo(late, addr)
+		server.WriteTo(expect[:len(expect)-1], addr)
+		server.WriteTo(expect, addr)
+	}()
+
+	rsp, err := client.Transact(expect)
+	if err != nil {
+		t.Fatalf("Failed to transact: %v.\n", err)
+	}
+	if !bytes.Equal(rsp, expect) {
+		t.Fatalf("Response mismatch: %x.\n", rsp)
+	}
+}